@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tmux alerts: when a window turns unread or starts waiting for
+// permission, cfg.TmuxAlert can ring the bell in the agent's pane, so
+// tmux's own bell flag and window-status-bell-style light up, and show
+// a display-message on every attached client.
+
+type tmuxAlert struct {
+	// Bell writes a BEL to the agent's pane.
+	Bell bool `json:"bell"`
+	// Message, if set, is shown on attached clients with
+	// display-message. {placeholders} as in push messages are filled
+	// first, then tmux expands #{formats} for the window.
+	Message string `json:"message"`
+	// DisplayTime is how long the message shows; zero uses tmux's
+	// display-time.
+	DisplayTime duration `json:"display_time"`
+	// On lists the events that alert: "unread", "permission" (default both).
+	On []string `json:"on"`
+}
+
+func (a tmuxAlert) enabled() bool {
+	return a.Bell || a.Message != ""
+}
+
+func (a tmuxAlert) wants(reason string) bool {
+	if len(a.On) == 0 {
+		return reason == "unread" || reason == "permission"
+	}
+	for _, r := range a.On {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func (a tmuxAlert) validate() error {
+	for _, r := range a.On {
+		if r != "unread" && r != "permission" {
+			return fmt.Errorf("tmux_alert: unknown event %q, want unread or permission", r)
+		}
+	}
+	return nil
+}
+
+var (
+	// ringBell writes a BEL to the terminal of target (a pane id or
+	// window).
+	ringBell = func(target string) error {
+		out, err := exec.Command("tmux", "display-message", "-p", "-t", target, "#{pane_tty}").Output()
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(strings.TrimSpace(string(out)), os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write([]byte("\a"))
+		return err
+	}
+	runTmux = func(args ...string) error {
+		return exec.Command("tmux", args...).Run()
+	}
+)
+
+// displayArgs is the display-message command for one client.
+func displayArgs(client, window, msg string, d time.Duration) []string {
+	args := []string{"display-message", "-c", client, "-t", window}
+	if d > 0 {
+		args = append(args, "-d", strconv.FormatInt(d.Milliseconds(), 10))
+	}
+	return append(args, msg)
+}
+
+// alertText renders a's message for ev. Event values are escaped so
+// that tmux doesn't expand formats inside agent output.
+func alertText(a tmuxAlert, ev statusEvent) string {
+	esc := func(s string) string { return strings.ReplaceAll(s, "#", "##") }
+	ev.Window, ev.PaneID = esc(ev.Window), esc(ev.PaneID)
+	ev.OldStatus, ev.NewStatus = esc(ev.OldStatus), esc(ev.NewStatus)
+	ev.Message = esc(ev.Message)
+	return renderPush(a.Message, ev, false)
+}
+
+// clientNames lists the attached tmux clients.
+func clientNames() []string {
+	out, err := listClientsOutput()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if _, name, ok := strings.Cut(line, " "); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fireTmuxAlert rings and displays ev (reason "unread" or "permission")
+// as configured in a.
+func fireTmuxAlert(a tmuxAlert, ev statusEvent) {
+	if !a.wants(ev.Reason) {
+		return
+	}
+	if a.Bell {
+		target := ev.PaneID
+		if target == "" {
+			target = ev.Window
+		}
+		if logDryRun {
+			log.Printf("dry-run: would ring the bell in %s (%s)", target, ev.Reason)
+		}
+		if !dryRun {
+			if err := ringBell(target); err != nil {
+				log.Printf("tmux_alert: bell in %s: %v", target, err)
+			}
+			// Our own bell is not a signal from the agent.
+			windowBell[ev.Window] = true
+		}
+	}
+	if a.Message != "" {
+		msg := alertText(a, ev)
+		for _, client := range clientNames() {
+			args := displayArgs(client, ev.Window, msg, a.DisplayTime.Duration)
+			if logDryRun {
+				log.Printf("dry-run: would run %s (%s)", shellQuote(args), ev.Reason)
+			}
+			if !dryRun {
+				runTmux(args...)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertText(t *testing.T) {
+	a := tmuxAlert{Message: "#{window_name} {agent}: {message}"}
+	ev := statusEvent{Window: "main:2", Agent: "claude", Message: "fixed #{bug} in #12"}
+	want := "#{window_name} Claude: fixed ##{bug} in ##12"
+	if got := alertText(a, ev); got != want {
+		t.Errorf("alertText = %q, want %q", got, want)
+	}
+}
+
+func TestDisplayArgs(t *testing.T) {
+	got := displayArgs("/dev/pts/3", "main:2", "hi", 4*time.Second)
+	want := []string{"display-message", "-c", "/dev/pts/3", "-t", "main:2", "-d", "4000", "hi"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("displayArgs = %q, want %q", got, want)
+	}
+	if got := displayArgs("c", "w", "hi", 0); len(got) != 6 {
+		t.Errorf("no display time should omit -d: %q", got)
+	}
+}
+
+func TestFireTmuxAlert(t *testing.T) {
+	origBell, origRun, origClients := ringBell, runTmux, listClientsOutput
+	defer func() { ringBell, runTmux, listClientsOutput = origBell, origRun, origClients }()
+	var rung, ran []string
+	ringBell = func(target string) error {
+		rung = append(rung, target)
+		return nil
+	}
+	runTmux = func(args ...string) error {
+		ran = append(ran, strings.Join(args, " "))
+		return nil
+	}
+	listClientsOutput = func() ([]byte, error) {
+		return []byte("1700000000 /dev/pts/1\n1700000001 /dev/pts/2\n"), nil
+	}
+
+	a := tmuxAlert{Bell: true, Message: "{agent} {reason}"}
+	fireTmuxAlert(a, statusEvent{Window: "main:2", PaneID: "%7", Agent: "codex", Reason: "permission"})
+	if !reflect.DeepEqual(rung, []string{"%7"}) {
+		t.Errorf("bell rung in %q", rung)
+	}
+	if !windowBell["main:2"] {
+		t.Error("our own bell should not read as the agent's")
+	}
+	delete(windowBell, "main:2")
+	want := []string{
+		"display-message -c /dev/pts/1 -t main:2 Codex permission",
+		"display-message -c /dev/pts/2 -t main:2 Codex permission",
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+
+	rung, ran = nil, nil
+	fireTmuxAlert(tmuxAlert{Bell: true, On: []string{"permission"}}, statusEvent{Window: "main:3", Reason: "unread"})
+	if len(rung) != 0 || len(ran) != 0 {
+		t.Error("events not in on should not alert")
+	}
+	fireTmuxAlert(tmuxAlert{Bell: true}, statusEvent{Window: "main:3", Reason: "unread"})
+	if !reflect.DeepEqual(rung, []string{"main:3"}) {
+		t.Errorf("without a pane id the bell should go to the window, got %q", rung)
+	}
+	delete(windowBell, "main:3")
+}
+
+func TestLoadConfig_TmuxAlert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"tmux_alert": {"bell": true, "display_time": "5s", "on": ["permission"]}}`), 0644)
+	c, err := loadConfig(path)
+	if err != nil || !c.TmuxAlert.enabled() || c.TmuxAlert.DisplayTime.Duration != 5*time.Second {
+		t.Fatalf("tmux_alert = %+v, %v", c.TmuxAlert, err)
+	}
+	os.WriteFile(path, []byte(`{"tmux_alert": {"bell": true, "on": ["exit"]}}`), 0644)
+	if _, err := loadConfig(path); err == nil {
+		t.Error("unknown event should be rejected")
+	}
+}
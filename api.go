@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Control API: line-delimited JSON over a Unix socket in stateDir().
+// Each request is one JSON object per line, {"method": ..., params},
+// answered by one {"ok": ..., "result"|"error": ...} line. `tmux-ai-status
+// ctl` is the matching client for shell scripts and tmux key bindings.
+
+var (
+	// daemonMu serialises detection cycles and API requests.
+	daemonMu sync.Mutex
+	// wake asks the main loop to run a cycle now, e.g. after mark-read.
+	wake = make(chan struct{}, 1)
+
+	// windowReports is the daemon's view of each agent window as of the
+	// last cycle.
+	windowReports = make(map[string]*windowReport)
+	// windowSnooze suppresses unread marking for a window until a time.
+	windowSnooze = make(map[string]time.Time)
+)
+
+// windowReport is what the API returns for a window.
+type windowReport struct {
+	Window       string     `json:"window"`
+	Session      string     `json:"session"`
+	PaneID       string     `json:"pane_id,omitempty"`
+	Agent        string     `json:"agent,omitempty"`
+	Status       string     `json:"status"` // detected status, e.g. "c 💤"
+	Name         string     `json:"name"`   // window name as rendered
+	Unread       bool       `json:"unread"`
+	Focused      bool       `json:"focused"`
+	Mode         string     `json:"mode,omitempty"`
+	ContextLeft  *int       `json:"context_left,omitempty"`
+	Task         string     `json:"task,omitempty"`
+	Result       string     `json:"result,omitempty"`
+	Model        string     `json:"model,omitempty"`
+	CostUSD      *float64   `json:"cost_usd,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	Muted        string     `json:"muted,omitempty"` // "notify" or "all"
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func newWindowReport(window string, focused bool, rawStatus, name string, footer paneFooter, agent agentProc, task childTask, now time.Time) *windowReport {
+	r := &windowReport{
+		Window:    window,
+		Session:   windowSession(window),
+		PaneID:    agent.paneID,
+		Agent:     agent.name,
+		Status:    rawStatus,
+		Name:      name,
+		Unread:    isUnread(window),
+		Focused:   focused,
+		Mode:      footer.mode,
+		Task:      task.icon,
+		Result:    footer.result,
+		Model:     footer.model,
+		UpdatedAt: now,
+	}
+	if r.Agent == "" {
+		r.Agent = agentFromStatus(rawStatus)
+	}
+	if footer.contextLeft >= 0 {
+		left := footer.contextLeft
+		r.ContextLeft = &left
+	}
+	if footer.model != "" && footer.costUSD >= 0 {
+		cost := footer.costUSD
+		r.CostUSD = &cost
+	}
+	if until, ok := windowSnooze[window]; ok && now.Before(until) {
+		r.SnoozedUntil = &until
+	}
+	return r
+}
+
+// windowSession returns the session part of "session:index".
+func windowSession(window string) string {
+	if i := strings.LastIndex(window, ":"); i >= 0 {
+		return window[:i]
+	}
+	return window
+}
+
+func agentFromStatus(status string) string {
+	switch statusPrefix(status) {
+	case "c ":
+		return "claude"
+	case "x ":
+		return "codex"
+	}
+	return ""
+}
+
+func isSnoozed(window string, now time.Time) bool {
+	until, ok := windowSnooze[window]
+	return ok && now.Before(until)
+}
+
+func apiSocketPath() string {
+	return filepath.Join(stateDir(), "daemon.sock")
+}
+
+type apiRequest struct {
+	Method   string `json:"method"`
+	Window   string `json:"window,omitempty"`   // window ("session:index") or pane id ("%3")
+	Duration string `json:"duration,omitempty"` // for snooze, e.g. "30m"
+	Level    string `json:"level,omitempty"`    // for mute: "notify" (default), "all" or "off"
+}
+
+type apiResponse struct {
+	OK     bool   `json:"ok"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// serveAPI listens on path until the listener fails. A socket left behind
+// by a dead daemon is replaced; a live one is an error.
+func serveAPI(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := checkPrivateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("another daemon is listening on %s", path)
+	}
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	if err := os.Chmod(path, 0600); err != nil {
+		return err
+	}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConn(conn)
+	}
+}
+
+func serveConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for sc.Scan() {
+		var req apiRequest
+		var resp apiResponse
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			resp = apiResponse{Error: "invalid request: " + err.Error()}
+		} else if req.Method == "subscribe" {
+			streamEvents(conn, enc)
+			return
+		} else {
+			daemonMu.Lock()
+			resp = handleRequest(req, time.Now())
+			daemonMu.Unlock()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handleRequest must be called with daemonMu held.
+func handleRequest(req apiRequest, now time.Time) apiResponse {
+	switch req.Method {
+	case "list-windows":
+		list := make([]*windowReport, 0, len(windowReports))
+		for _, r := range windowReports {
+			list = append(list, r)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Window < list[j].Window })
+		return apiResponse{OK: true, Result: list}
+
+	case "list-panes":
+		list := paneReports
+		if list == nil {
+			list = []*paneReport{}
+		}
+		return apiResponse{OK: true, Result: list}
+
+	case "get-window":
+		r, err := findReport(req.Window)
+		if err != nil {
+			return apiResponse{Error: err.Error()}
+		}
+		return apiResponse{OK: true, Result: r}
+
+	case "explain":
+		lines, err := traceFor(req.Window)
+		if err != nil {
+			return apiResponse{Error: err.Error()}
+		}
+		return apiResponse{OK: true, Result: lines}
+
+	case "mark-read":
+		r, err := findReport(req.Window)
+		if err != nil {
+			return apiResponse{Error: err.Error()}
+		}
+		clearUnread(r.Window)
+		clearPaneUnread(r.Window)
+		r.Unread = false
+		wakeDaemon()
+		return apiResponse{OK: true, Result: r}
+
+	case "mark-all-read":
+		for _, r := range windowReports {
+			clearUnread(r.Window)
+			clearPaneUnread(r.Window)
+			r.Unread = false
+		}
+		wakeDaemon()
+		return apiResponse{OK: true}
+
+	case "snooze":
+		r, err := findReport(req.Window)
+		if err != nil {
+			return apiResponse{Error: err.Error()}
+		}
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return apiResponse{Error: fmt.Sprintf("invalid duration %q", req.Duration)}
+		}
+		until := now.Add(d)
+		windowSnooze[r.Window] = until
+		clearUnread(r.Window)
+		clearPaneUnread(r.Window)
+		r.Unread = false
+		r.SnoozedUntil = &until
+		wakeDaemon()
+		return apiResponse{OK: true, Result: r}
+
+	case "mute":
+		r, err := findReport(req.Window)
+		if err != nil {
+			return apiResponse{Error: err.Error()}
+		}
+		level := muteNotify
+		if req.Level != "" {
+			if level, err = parseMuteLevel(req.Level); err != nil {
+				return apiResponse{Error: err.Error()}
+			}
+		}
+		if err := setMuteOption(r.Window, level); err != nil {
+			return apiResponse{Error: fmt.Sprintf("set %s: %v", muteOption, err)}
+		}
+		if level == muteOff {
+			delete(windowMute, r.Window)
+		} else {
+			windowMute[r.Window] = level
+		}
+		r.Muted = muteLevel(r.Window, now)
+		wakeDaemon()
+		return apiResponse{OK: true, Result: r}
+
+	case "reload":
+		c, err := loadConfig(configPath())
+		if err != nil {
+			return apiResponse{Error: err.Error()}
+		}
+		cfg = c
+		wakeDaemon()
+		return apiResponse{OK: true}
+	}
+	return apiResponse{Error: fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+// findReport resolves a window ("session:index") or pane id ("%3").
+func findReport(target string) (*windowReport, error) {
+	if target == "" {
+		return nil, errors.New("missing window")
+	}
+	if r, ok := windowReports[target]; ok {
+		return r, nil
+	}
+	for _, r := range windowReports {
+		if r.PaneID == target {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("no agent window %q", target)
+}
+
+func wakeDaemon() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// callAPI sends one request to the running daemon.
+func callAPI(path string, req apiRequest) (apiResponse, error) {
+	if err := checkPrivateDir(filepath.Dir(path)); err != nil {
+		return apiResponse{}, fmt.Errorf("daemon not reachable: %w", err)
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return apiResponse{}, fmt.Errorf("daemon not reachable: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return apiResponse{}, err
+	}
+	var resp apiResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return apiResponse{}, err
+	}
+	return resp, nil
+}
+
+const ctlUsage = `usage: tmux-ai-status ctl list-windows
+       tmux-ai-status ctl list-panes
+       tmux-ai-status ctl get-window TARGET
+       tmux-ai-status ctl explain TARGET
+       tmux-ai-status ctl mark-read TARGET
+       tmux-ai-status ctl mark-all-read
+       tmux-ai-status ctl snooze TARGET DURATION
+       tmux-ai-status ctl mute TARGET [notify|all|off]
+       tmux-ai-status ctl reload
+       tmux-ai-status ctl subscribe
+TARGET is a window ("main:2") or pane id ("%7").
+`
+
+func runCtl(args []string) int {
+	req, err := parseCtlArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status ctl: %v\n%s", err, ctlUsage)
+		return 2
+	}
+	if req.Method == "subscribe" {
+		if err := subscribeAPI(apiSocketPath(), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "tmux-ai-status ctl: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	resp, err := callAPI(apiSocketPath(), req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status ctl: %v\n", err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status ctl: %s\n", resp.Error)
+		return 1
+	}
+	if resp.Result != nil {
+		out, _ := json.MarshalIndent(resp.Result, "", "  ")
+		fmt.Println(string(out))
+	}
+	return 0
+}
+
+func parseCtlArgs(args []string) (apiRequest, error) {
+	if len(args) == 0 {
+		return apiRequest{}, errors.New("missing method")
+	}
+	req := apiRequest{Method: args[0]}
+	want := 0
+	switch req.Method {
+	case "list-windows", "list-panes", "mark-all-read", "reload", "subscribe":
+	case "get-window", "explain", "mark-read":
+		want = 1
+	case "snooze":
+		want = 2
+	case "mute":
+		want = 1
+		if len(args) == 3 {
+			req.Level = args[2]
+			want = 2
+		}
+	default:
+		return req, fmt.Errorf("unknown method %q", req.Method)
+	}
+	if len(args)-1 != want {
+		return req, fmt.Errorf("%s takes %d argument(s)", req.Method, want)
+	}
+	if want >= 1 {
+		req.Window = args[1]
+	}
+	if req.Method == "snooze" {
+		req.Duration = args[2]
+	}
+	return req, nil
+}
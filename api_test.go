@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func seedReports(t *testing.T, reports ...*windowReport) {
+	t.Helper()
+	orig := windowReports
+	windowReports = make(map[string]*windowReport)
+	for _, r := range reports {
+		windowReports[r.Window] = r
+	}
+	t.Cleanup(func() {
+		for _, r := range reports {
+			statusStateMu.Lock()
+			delete(statusState, r.Window)
+			statusStateMu.Unlock()
+			delete(windowSnooze, r.Window)
+		}
+		windowReports = orig
+	})
+}
+
+func TestHandleRequest_ListAndGet(t *testing.T) {
+	seedReports(t,
+		&windowReport{Window: "work:2", PaneID: "%5", Status: "x 🧠"},
+		&windowReport{Window: "work:1", PaneID: "%3", Status: "c 💤"},
+	)
+
+	resp := handleRequest(apiRequest{Method: "list-windows"}, time.Now())
+	list, ok := resp.Result.([]*windowReport)
+	if !resp.OK || !ok || len(list) != 2 || list[0].Window != "work:1" {
+		t.Fatalf("list-windows = %+v", resp)
+	}
+
+	resp = handleRequest(apiRequest{Method: "get-window", Window: "%5"}, time.Now())
+	if r, _ := resp.Result.(*windowReport); !resp.OK || r.Window != "work:2" {
+		t.Errorf("get-window by pane id = %+v", resp)
+	}
+	resp = handleRequest(apiRequest{Method: "get-window", Window: "nope:9"}, time.Now())
+	if resp.OK || resp.Error == "" {
+		t.Errorf("unknown window should fail, got %+v", resp)
+	}
+	resp = handleRequest(apiRequest{Method: "frobnicate"}, time.Now())
+	if resp.OK {
+		t.Error("unknown method should fail")
+	}
+}
+
+func TestHandleRequest_MarkRead(t *testing.T) {
+	seedReports(t,
+		&windowReport{Window: "api:1", Status: "c 💤", Unread: true},
+		&windowReport{Window: "api:2", Status: "x 💤", Unread: true},
+	)
+	markUnread("api:1")
+	markUnread("api:2")
+
+	if resp := handleRequest(apiRequest{Method: "mark-read", Window: "api:1"}, time.Now()); !resp.OK {
+		t.Fatalf("mark-read: %+v", resp)
+	}
+	if isUnread("api:1") || !isUnread("api:2") {
+		t.Error("mark-read should only clear the target window")
+	}
+	if resp := handleRequest(apiRequest{Method: "mark-all-read"}, time.Now()); !resp.OK {
+		t.Fatalf("mark-all-read: %+v", resp)
+	}
+	if isUnread("api:2") || windowReports["api:2"].Unread {
+		t.Error("mark-all-read should clear every window")
+	}
+}
+
+func TestHandleRequest_Snooze(t *testing.T) {
+	seedReports(t, &windowReport{Window: "api:3", Status: "c 💤"})
+	now := time.Now()
+
+	resp := handleRequest(apiRequest{Method: "snooze", Window: "api:3", Duration: "30m"}, now)
+	if !resp.OK {
+		t.Fatalf("snooze: %+v", resp)
+	}
+	if !isSnoozed("api:3", now.Add(29*time.Minute)) || isSnoozed("api:3", now.Add(31*time.Minute)) {
+		t.Error("snooze should last 30m")
+	}
+	resp = handleRequest(apiRequest{Method: "snooze", Window: "api:3", Duration: "soon"}, now)
+	if resp.OK {
+		t.Error("invalid duration should fail")
+	}
+}
+
+func TestHandleRequest_Reload(t *testing.T) {
+	orig := cfg
+	defer func() { cfg = orig }()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"format": "{status}!"}`), 0644)
+	t.Setenv("TMUX_AI_STATUS_CONFIG", path)
+
+	if resp := handleRequest(apiRequest{Method: "reload"}, time.Now()); !resp.OK {
+		t.Fatalf("reload: %+v", resp)
+	}
+	if cfg.Format != "{status}!" {
+		t.Errorf("format after reload = %q", cfg.Format)
+	}
+
+	os.WriteFile(path, []byte(`{`), 0644)
+	if resp := handleRequest(apiRequest{Method: "reload"}, time.Now()); resp.OK {
+		t.Error("invalid config should fail to reload")
+	}
+	if cfg.Format != "{status}!" {
+		t.Error("failed reload should keep the running config")
+	}
+}
+
+func TestServeAPI_RoundTrip(t *testing.T) {
+	seedReports(t, &windowReport{Window: "sock:1", Status: "c 🧠"})
+	path := filepath.Join(privateTempDir(t), "daemon.sock")
+	go serveAPI(path)
+
+	var resp apiResponse
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = callAPI(path, apiRequest{Method: "get-window", Window: "sock:1"}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("callAPI: %v", err)
+	}
+	r, _ := resp.Result.(map[string]any)
+	if !resp.OK || r["window"] != "sock:1" || r["status"] != "c 🧠" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if err := serveAPI(path); err == nil {
+		t.Error("second daemon on the same socket should fail")
+	}
+}
+
+func TestParseCtlArgs(t *testing.T) {
+	req, err := parseCtlArgs([]string{"snooze", "main:2", "1h"})
+	if err != nil || req.Method != "snooze" || req.Window != "main:2" || req.Duration != "1h" {
+		t.Errorf("snooze args = %+v, %v", req, err)
+	}
+	req, err = parseCtlArgs([]string{"mute", "main:2", "all"})
+	if err != nil || req.Method != "mute" || req.Window != "main:2" || req.Level != "all" || req.Duration != "" {
+		t.Errorf("mute args = %+v, %v", req, err)
+	}
+	if req, err := parseCtlArgs([]string{"mute", "main:2"}); err != nil || req.Level != "" {
+		t.Errorf("mute without level = %+v, %v", req, err)
+	}
+	if _, err := parseCtlArgs([]string{"mark-read"}); err == nil {
+		t.Error("missing target should fail")
+	}
+	if _, err := parseCtlArgs([]string{"explode"}); err == nil {
+		t.Error("unknown method should fail")
+	}
+	if _, err := parseCtlArgs(nil); err == nil {
+		t.Error("no method should fail")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Bell and title signals: agents can ring the terminal bell when they
+// need input (Claude's terminal_bell notification channel), which tmux
+// records as window_bell_flag, and both set the pane title with OSC
+// sequences. A bell, or a title change while the agent is idle, marks
+// the window unread even when the screen signatures don't change.
+// Titles are followed per pane, so a window whose agents take turns at
+// the top of the status order doesn't look like a retitled pane.
+
+var (
+	windowBell = make(map[string]bool)   // bell flag last cycle
+	paneTitle  = make(map[string]string) // paneTitleSig last cycle, by pane id
+)
+
+// paneTitleSig strips the status glyphs agents prefix to their title
+// (Claude's "✳" when idle, a braille spinner while working), so that
+// only a change of wording counts.
+func paneTitleSig(title string) string {
+	return strings.TrimSpace(strings.TrimLeftFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
+
+// trackPaneTitle records an agent pane's title and returns the one it
+// replaced, or "" when the wording didn't change.
+func trackPaneTitle(paneID, title string) string {
+	sig := paneTitleSig(title)
+	prev := paneTitle[paneID]
+	paneTitle[paneID] = sig
+	if prev == "" || sig == "" || sig == prev {
+		return ""
+	}
+	return prev
+}
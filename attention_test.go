@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestPaneTitleSig(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"✳ Fix the login bug", "Fix the login bug"},
+		{"⠐ Fix the login bug", "Fix the login bug"},
+		{"⠂  Fix the login bug ", "Fix the login bug"},
+		{"codex", "codex"},
+		{"✳", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := paneTitleSig(tt.title); got != tt.want {
+			t.Errorf("paneTitleSig(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTrackPaneTitle(t *testing.T) {
+	defer func() {
+		delete(paneTitle, "%1")
+		delete(paneTitle, "%2")
+	}()
+	steps := []struct {
+		pane, title, want string
+	}{
+		{"%1", "✳ Fix the login bug", ""}, // first sight
+		{"%2", "✳ Write docs", ""},
+		{"%1", "⠐ Fix the login bug", ""}, // spinner only
+		{"%2", "✳ Write docs", ""},        // the other pane, unchanged
+		{"%1", "✳ Fix the logout bug", "Fix the login bug"},
+		{"%1", "✳", ""},
+	}
+	for _, st := range steps {
+		if got := trackPaneTitle(st.pane, st.title); got != st.want {
+			t.Errorf("trackPaneTitle(%q, %q) = %q, want %q", st.pane, st.title, got, st.want)
+		}
+	}
+}
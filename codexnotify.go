@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Codex notify integration: with `notify = ["tmux-ai-status", "codex-notify"]`
+// in ~/.codex/config.toml, Codex runs us with a JSON payload as the last
+// argument on agent-turn-complete. The turn is recorded for the calling
+// pane and used as the completion signature, so unread no longer depends
+// on spotting "─ Worked for" in the pane.
+
+// codexTurn is what `codex-notify` records for a pane.
+type codexTurn struct {
+	TurnID  string    `json:"turn_id"`
+	Message string    `json:"message,omitempty"` // last assistant message
+	At      time.Time `json:"at"`
+}
+
+type codexNotifyPayload struct {
+	Type                 string `json:"type"`
+	TurnID               string `json:"turn-id"`
+	LastAssistantMessage string `json:"last-assistant-message"`
+}
+
+func runCodexNotify(args []string) int {
+	if len(args) > 0 && args[0] == "install" {
+		return runCodexNotifyInstall(args[1:])
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "tmux-ai-status codex-notify: missing JSON payload argument")
+		return 1
+	}
+	if err := recordCodexNotify(args[len(args)-1], os.Getenv("TMUX_PANE"), time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status codex-notify: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func recordCodexNotify(payload, paneID string, now time.Time) error {
+	if paneID == "" {
+		return nil // not running inside tmux
+	}
+	var p codexNotifyPayload
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	if p.Type != "agent-turn-complete" {
+		return nil
+	}
+	turnID := p.TurnID
+	if turnID == "" {
+		turnID = now.Format(time.RFC3339Nano)
+	}
+	return writeState(paneStatePath(paneID, "codex"), codexTurn{
+		TurnID:  turnID,
+		Message: p.LastAssistantMessage,
+		At:      now,
+	})
+}
+
+func readCodexTurn(paneID string) *codexTurn {
+	if paneID == "" {
+		return nil
+	}
+	var turn codexTurn
+	if ok, err := readState(paneStatePath(paneID, "codex"), &turn); !ok || err != nil {
+		return nil
+	}
+	return &turn
+}
+
+// codexTurnSignature returns the completion signature for a Codex pane's
+// last notified turn, or "" when Codex has not notified us.
+func codexTurnSignature(status string, turn *codexTurn) string {
+	if turn == nil || !strings.HasPrefix(status, "x ") {
+		return ""
+	}
+	return "codex-notify:" + turn.TurnID
+}
+
+// runCodexNotifyInstall adds the notify entry to ~/.codex/config.toml.
+func runCodexNotifyInstall(args []string) int {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tmux-ai-status: %v\n", err)
+			return 1
+		}
+		path = filepath.Join(home, ".codex", "config.toml")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status: %v\n", err)
+		return 1
+	}
+	added, err := installCodexNotify(path, exe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status: %v\n", err)
+		return 1
+	}
+	if added {
+		fmt.Printf("added notify to %s\n", path)
+	} else {
+		fmt.Printf("%s already notifies tmux-ai-status\n", path)
+	}
+	return 0
+}
+
+var errNotifyTaken = errors.New("config already has a different notify program; add \"codex-notify\" to it by hand")
+
+// installCodexNotify inserts a top-level notify key. TOML only allows
+// top-level keys before the first table, so it goes at the top of the file.
+func installCodexNotify(path, exe string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			break // only top-level keys matter
+		}
+		key, _, found := strings.Cut(trimmed, "=")
+		if !found || strings.TrimSpace(key) != "notify" {
+			continue
+		}
+		if strings.Contains(trimmed, "codex-notify") {
+			return false, nil
+		}
+		return false, errNotifyTaken
+	}
+
+	entry := fmt.Sprintf("notify = [%q, \"codex-notify\"]\n", exe)
+	if len(data) > 0 {
+		entry += "\n"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, append([]byte(entry), data...), 0644)
+}
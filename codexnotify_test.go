@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordCodexNotify(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	now := time.Now()
+
+	payload := `{"type":"agent-turn-complete","turn-id":"12345","input-messages":["fix the bug"],"last-assistant-message":"Fixed the off-by-one."}`
+	if err := recordCodexNotify(payload, "%3", now); err != nil {
+		t.Fatalf("recordCodexNotify: %v", err)
+	}
+	turn := readCodexTurn("%3")
+	if turn == nil {
+		t.Fatal("expected stored turn")
+	}
+	if turn.TurnID != "12345" || turn.Message != "Fixed the off-by-one." {
+		t.Errorf("unexpected turn: %+v", turn)
+	}
+	if got := codexTurnSignature("x 💤", turn); got != "codex-notify:12345" {
+		t.Errorf("codexTurnSignature() = %q", got)
+	}
+	if got := codexTurnSignature("c 💤", turn); got != "" {
+		t.Errorf("claude panes should not use codex turns, got %q", got)
+	}
+}
+
+func TestRecordCodexNotify_IgnoresOtherTypes(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := recordCodexNotify(`{"type":"something-else"}`, "%3", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if readCodexTurn("%3") != nil {
+		t.Error("unknown notification types should not be recorded")
+	}
+	if err := recordCodexNotify(`nope`, "%3", time.Now()); err == nil {
+		t.Error("expected decode error")
+	}
+}
+
+func TestInstallCodexNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("model = \"gpt-5.3-codex\"\n\n[projects.\"/src\"]\ntrust_level = \"trusted\"\n"), 0644)
+
+	added, err := installCodexNotify(path, "/bin/tmux-ai-status")
+	if err != nil || !added {
+		t.Fatalf("install: added=%v err=%v", added, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "notify = [\"/bin/tmux-ai-status\", \"codex-notify\"]\n") {
+		t.Errorf("notify should be a top-level key at the top, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "trust_level = \"trusted\"") {
+		t.Error("existing config should be kept")
+	}
+
+	added, err = installCodexNotify(path, "/bin/tmux-ai-status")
+	if err != nil || added {
+		t.Errorf("second install should be a no-op, got added=%v err=%v", added, err)
+	}
+}
+
+func TestInstallCodexNotify_ExistingProgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("notify = [\"notify-send\", \"Codex\"]\n"), 0644)
+	if _, err := installCodexNotify(path, "/bin/tmux-ai-status"); !errors.Is(err, errNotifyTaken) {
+		t.Errorf("expected errNotifyTaken, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// config is read at startup from configPath(), and again by the API's
+// reload method, which swaps cfg under daemonMu between cycles. Every
+// field is optional; missing fields keep the defaults below.
+//
+// Each cycle reads cfg afresh, and the pusher looks up cfg.Push per event,
+// so every setting takes effect on reload. What reload leaves alone is
+// set up once at startup: the control socket (its path comes from
+// XDG_RUNTIME_DIR, not the config), the desktop notifier's D-Bus
+// connection, the pusher and its per-target workers (a removed target's
+// worker stays idle), and the --dry-run flags.
+type config struct {
+	// Format is the window name template. Placeholders:
+	//   {status}  agent prefix + status icon, e.g. "c 🧠"
+	//   {result}  outcome of the last test/build task, e.g. " 🧪❌"
+	//   {mode}    mode badge (plan, accept edits, full auto), with a leading space
+	//   {context} low-context warning badge, with a leading space
+	//   {model}   Claude model name from `statusline`, with a leading space
+	//   {cost}    Claude session cost from `statusline`, e.g. " $1.20"
+	Format string `json:"format"`
+
+	// StatuslineFormat is what `tmux-ai-status statusline` prints inside
+	// Claude. Placeholders: {model}, {cost}, {dir}, {cwd}, {version}.
+	StatuslineFormat string `json:"statusline_format"`
+
+	// ExitLinger is how long a window keeps its last agent status plus
+	// the exit marker after the agent process goes away.
+	ExitLinger duration `json:"exit_linger"`
+
+	// ResultLinger is how long the ✅/❌ outcome of a finished test or
+	// build task stays on the tab.
+	ResultLinger duration `json:"result_linger"`
+
+	// HookMaxAge is how long an event from `tmux-ai-status hook` is
+	// trusted over pane scraping.
+	HookMaxAge duration `json:"hook_max_age"`
+
+	// Notify sends a desktop notification when a window turns unread.
+	Notify bool `json:"notify"`
+
+	// NotifyCoalesce is how long to wait for other windows to turn
+	// unread so that they share one notification.
+	NotifyCoalesce duration `json:"notify_coalesce"`
+
+	// DesktopIdleAfter and PushIdleAfter route notifications by how long
+	// the user's tmux clients have seen no input: desktop notifications
+	// after the first, push targets after the second. "0s" always sends.
+	DesktopIdleAfter duration `json:"desktop_idle_after"`
+	PushIdleAfter    duration `json:"push_idle_after"`
+
+	// Remind re-notifies and restyles windows left unread.
+	Remind remindConfig `json:"remind"`
+
+	// QuietHours mutes every window's notifications during the listed
+	// spans; with QuietHoursUnread they aren't marked unread either.
+	QuietHours       []quietRange `json:"quiet_hours"`
+	QuietHoursUnread bool         `json:"quiet_hours_unread"`
+
+	// MultiPane lists every agent of a window in its name, tracks unread
+	// per pane and sets @ai_pane_status on each agent pane.
+	MultiPane bool `json:"multi_pane"`
+
+	// SummaryFormat is the text of the @ai_summary and
+	// @ai_session_summary tmux options. Placeholders: {working},
+	// {unread}, {permission}, {idle}, {interrupted}, {exited} (icon and
+	// count, empty when zero) and {total}.
+	SummaryFormat string `json:"summary_format"`
+
+	// TmuxAlert rings the tmux bell or shows a display-message when a
+	// window turns unread or asks for permission.
+	TmuxAlert tmuxAlert `json:"tmux_alert"`
+
+	// Push lists HTTP endpoints (webhook, ntfy, slack) that receive
+	// status events.
+	Push []pushTarget `json:"push"`
+
+	// OnTransition runs commands when a window changes status class.
+	OnTransition []transitionHook `json:"on_transition"`
+
+	// TransitionConcurrency caps how many OnTransition commands run at
+	// once; more are queued.
+	TransitionConcurrency int `json:"transition_concurrency"`
+}
+
+func defaultConfig() config {
+	return config{
+		Format:                "{status}{result}{mode}{context}",
+		StatuslineFormat:      "{model} · {cost} · {dir}",
+		ExitLinger:            duration{60 * time.Second},
+		ResultLinger:          duration{30 * time.Second},
+		HookMaxAge:            duration{10 * time.Minute},
+		NotifyCoalesce:        duration{3 * time.Second},
+		DesktopIdleAfter:      duration{2 * time.Minute},
+		PushIdleAfter:         duration{10 * time.Minute},
+		Remind:                remindConfig{Style: "fg=white,bg=red,blink"},
+		SummaryFormat:         "{working} {unread} {permission}",
+		TransitionConcurrency: 4,
+	}
+}
+
+// duration reads Go duration strings ("90s", "5m") from JSON.
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+var cfg = defaultConfig()
+
+// configPath honours $TMUX_AI_STATUS_CONFIG, then $XDG_CONFIG_HOME,
+// then ~/.config.
+func configPath() string {
+	if p := os.Getenv("TMUX_AI_STATUS_CONFIG"); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "tmux-ai-status", "config.json")
+}
+
+// loadConfig returns the defaults when the file does not exist.
+func loadConfig(path string) (config, error) {
+	c := defaultConfig()
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Format == "" {
+		c.Format = defaultConfig().Format
+	}
+	if c.StatuslineFormat == "" {
+		c.StatuslineFormat = defaultConfig().StatuslineFormat
+	}
+	for _, t := range c.Push {
+		if err := t.validate(); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, h := range c.OnTransition {
+		if err := h.validate(); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, q := range c.QuietHours {
+		if err := q.validate(); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := c.TmuxAlert.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.Remind.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
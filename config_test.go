@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_Missing(t *testing.T) {
+	c, err := loadConfig(filepath.Join(t.TempDir(), "nope.json"))
+	if err != nil {
+		t.Fatalf("missing config should not error: %v", err)
+	}
+	if c.Format != defaultConfig().Format {
+		t.Errorf("expected default format, got %q", c.Format)
+	}
+}
+
+func TestLoadConfig_Overrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"format": "{mode}{status}"}`), 0644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Format != "{mode}{status}" {
+		t.Errorf("format = %q", c.Format)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"format": `), 0644)
+	c, err := loadConfig(path)
+	if err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+	if c.Format != defaultConfig().Format {
+		t.Errorf("invalid config should fall back to defaults, got %q", c.Format)
+	}
+}
+
+func TestLoadConfig_Duration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"exit_linger": "5m"}`), 0644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.ExitLinger.Duration != 5*time.Minute {
+		t.Errorf("exit_linger = %v, want 5m", c.ExitLinger)
+	}
+
+	os.WriteFile(path, []byte(`{"exit_linger": 30}`), 0644)
+	if _, err := loadConfig(path); err == nil {
+		t.Error("expected error for numeric duration")
+	}
+}
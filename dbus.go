@@ -0,0 +1,710 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A minimal D-Bus client: enough of the wire protocol to call methods on
+// the session bus and receive signals, so desktop notifications need no
+// third-party module or external tool.
+
+const (
+	dbusMethodCall   = 1
+	dbusMethodReturn = 2
+	dbusError        = 3
+	dbusSignal       = 4
+)
+
+// Header field codes.
+const (
+	dbusFieldPath        = 1
+	dbusFieldInterface   = 2
+	dbusFieldMember      = 3
+	dbusFieldErrorName   = 4
+	dbusFieldReplySerial = 5
+	dbusFieldDestination = 6
+	dbusFieldSender      = 7
+	dbusFieldSignature   = 8
+)
+
+const dbusCallTimeout = 5 * time.Second
+
+type dbusMessage struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        string
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   string
+	Body        []any
+}
+
+// dbusVariant is a value of type "v".
+type dbusVariant struct {
+	Sig   string
+	Value any
+}
+
+// dbusNextType returns the length of the first complete type in sig.
+func dbusNextType(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("dbus: empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		n, err := dbusNextType(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		closer := byte(')')
+		if sig[0] == '{' {
+			closer = '}'
+		}
+		i := 1
+		for i < len(sig) && sig[i] != closer {
+			n, err := dbusNextType(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		if i >= len(sig) {
+			return 0, fmt.Errorf("dbus: unterminated signature %q", sig)
+		}
+		return i + 1, nil
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 's', 'o', 'g', 'v', 'h':
+		return 1, nil
+	}
+	return 0, fmt.Errorf("dbus: unsupported type %q", sig[0])
+}
+
+// dbusSplitSig splits a signature into its complete types.
+func dbusSplitSig(sig string) ([]string, error) {
+	var types []string
+	for sig != "" {
+		n, err := dbusNextType(sig)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, sig[:n])
+		sig = sig[n:]
+	}
+	return types, nil
+}
+
+func dbusAlignment(t byte) int {
+	switch t {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 4
+}
+
+// dbusEncoder appends little-endian values to buf. Offsets, and so
+// padding, count from the start of buf.
+type dbusEncoder struct {
+	buf []byte
+}
+
+func (e *dbusEncoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *dbusEncoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *dbusEncoder) values(sig string, vals []any) error {
+	types, err := dbusSplitSig(sig)
+	if err != nil {
+		return err
+	}
+	if len(types) != len(vals) {
+		return fmt.Errorf("dbus: signature %q wants %d values, got %d", sig, len(types), len(vals))
+	}
+	for i, t := range types {
+		if err := e.value(t, vals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *dbusEncoder) value(sig string, v any) error {
+	bad := func() error { return fmt.Errorf("dbus: cannot encode %T as %q", v, sig) }
+	switch sig[0] {
+	case 'y':
+		b, ok := v.(byte)
+		if !ok {
+			return bad()
+		}
+		e.buf = append(e.buf, b)
+	case 'b':
+		b, ok := v.(bool)
+		if !ok {
+			return bad()
+		}
+		var u uint32
+		if b {
+			u = 1
+		}
+		e.uint32(u)
+	case 'i':
+		i, ok := v.(int32)
+		if !ok {
+			return bad()
+		}
+		e.uint32(uint32(i))
+	case 'u':
+		u, ok := v.(uint32)
+		if !ok {
+			return bad()
+		}
+		e.uint32(u)
+	case 's', 'o':
+		s, ok := v.(string)
+		if !ok {
+			return bad()
+		}
+		e.uint32(uint32(len(s)))
+		e.buf = append(e.buf, s...)
+		e.buf = append(e.buf, 0)
+	case 'g':
+		s, ok := v.(string)
+		if !ok || len(s) > 255 {
+			return bad()
+		}
+		e.buf = append(e.buf, byte(len(s)))
+		e.buf = append(e.buf, s...)
+		e.buf = append(e.buf, 0)
+	case 'v':
+		vv, ok := v.(dbusVariant)
+		if !ok {
+			return bad()
+		}
+		if err := e.value("g", vv.Sig); err != nil {
+			return err
+		}
+		return e.value(vv.Sig, vv.Value)
+	case 'a':
+		elem := sig[1:]
+		var items []any
+		switch a := v.(type) {
+		case []string:
+			for _, s := range a {
+				items = append(items, s)
+			}
+		case map[string]dbusVariant:
+			if elem != "{sv}" {
+				return bad()
+			}
+			for k, val := range a {
+				items = append(items, []any{k, val})
+			}
+		case []any:
+			items = a
+		default:
+			return bad()
+		}
+		e.uint32(0)
+		lenAt := len(e.buf) - 4
+		e.align(dbusAlignment(elem[0]))
+		start := len(e.buf)
+		for _, item := range items {
+			if err := e.value(elem, item); err != nil {
+				return err
+			}
+		}
+		binary.LittleEndian.PutUint32(e.buf[lenAt:], uint32(len(e.buf)-start))
+	case '(', '{':
+		fields, ok := v.([]any)
+		if !ok {
+			return bad()
+		}
+		e.align(8)
+		return e.values(sig[1:len(sig)-1], fields)
+	default:
+		return bad()
+	}
+	return nil
+}
+
+// dbusDecoder reads values from buf; off counts from the message start.
+type dbusDecoder struct {
+	buf   []byte
+	off   int
+	order binary.ByteOrder
+}
+
+var errDBusShort = errors.New("dbus: message too short")
+
+func (d *dbusDecoder) align(n int) error {
+	for d.off%n != 0 {
+		d.off++
+	}
+	if d.off > len(d.buf) {
+		return errDBusShort
+	}
+	return nil
+}
+
+func (d *dbusDecoder) take(n int) ([]byte, error) {
+	if n < 0 || d.off+n > len(d.buf) {
+		return nil, errDBusShort
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *dbusDecoder) values(sig string) ([]any, error) {
+	types, err := dbusSplitSig(sig)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]any, 0, len(types))
+	for _, t := range types {
+		v, err := d.value(t)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+func (d *dbusDecoder) value(sig string) (any, error) {
+	if err := d.align(dbusAlignment(sig[0])); err != nil {
+		return nil, err
+	}
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'n', 'q':
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'b', 'i', 'u', 'h':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		u := d.order.Uint32(b)
+		switch sig[0] {
+		case 'b':
+			return u != 0, nil
+		case 'i':
+			return int32(u), nil
+		}
+		return u, nil
+	case 'x', 't', 'd':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		u := d.order.Uint64(b)
+		switch sig[0] {
+		case 'x':
+			return int64(u), nil
+		case 'd':
+			return math.Float64frombits(u), nil
+		}
+		return u, nil
+	case 's', 'o':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(d.order.Uint32(b)) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'g':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(b[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'v':
+		s, err := d.value("g")
+		if err != nil {
+			return nil, err
+		}
+		inner := s.(string)
+		if n, err := dbusNextType(inner); err != nil || n != len(inner) {
+			return nil, fmt.Errorf("dbus: bad variant signature %q", inner)
+		}
+		v, err := d.value(inner)
+		return dbusVariant{Sig: inner, Value: v}, err
+	case 'a':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		n := int(d.order.Uint32(b))
+		elem := sig[1:]
+		if err := d.align(dbusAlignment(elem[0])); err != nil {
+			return nil, err
+		}
+		end := d.off + n
+		if end > len(d.buf) {
+			return nil, errDBusShort
+		}
+		items := []any{}
+		for d.off < end {
+			v, err := d.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case '(', '{':
+		return d.values(sig[1 : len(sig)-1])
+	}
+	return nil, fmt.Errorf("dbus: unsupported type %q", sig[0])
+}
+
+// marshal encodes m with the given serial.
+func (m *dbusMessage) marshal(serial uint32) ([]byte, error) {
+	var body dbusEncoder
+	if err := body.values(m.Signature, m.Body); err != nil {
+		return nil, err
+	}
+
+	var fields []any
+	addField := func(code byte, sig string, v any, set bool) {
+		if set {
+			fields = append(fields, []any{code, dbusVariant{Sig: sig, Value: v}})
+		}
+	}
+	addField(dbusFieldPath, "o", m.Path, m.Path != "")
+	addField(dbusFieldInterface, "s", m.Interface, m.Interface != "")
+	addField(dbusFieldMember, "s", m.Member, m.Member != "")
+	addField(dbusFieldErrorName, "s", m.ErrorName, m.ErrorName != "")
+	addField(dbusFieldReplySerial, "u", m.ReplySerial, m.ReplySerial != 0)
+	addField(dbusFieldDestination, "s", m.Destination, m.Destination != "")
+	addField(dbusFieldSender, "s", m.Sender, m.Sender != "")
+	addField(dbusFieldSignature, "g", m.Signature, m.Signature != "")
+
+	e := dbusEncoder{buf: []byte{'l', m.Type, m.Flags, 1}}
+	e.uint32(uint32(len(body.buf)))
+	e.uint32(serial)
+	if err := e.value("a(yv)", fields); err != nil {
+		return nil, err
+	}
+	e.align(8)
+	return append(e.buf, body.buf...), nil
+}
+
+// readDBusMessage reads one message from r.
+func readDBusMessage(r io.Reader) (*dbusMessage, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("dbus: bad endianness %q", fixed[0])
+	}
+	bodyLen := int(order.Uint32(fixed[4:]))
+	fieldsLen := int(order.Uint32(fixed[12:]))
+	headerLen := 16 + fieldsLen
+	if pad := headerLen % 8; pad != 0 {
+		headerLen += 8 - pad
+	}
+	if fieldsLen > 1<<20 || bodyLen > 1<<26 {
+		return nil, errors.New("dbus: message too large")
+	}
+	buf := make([]byte, headerLen+bodyLen)
+	copy(buf, fixed)
+	if _, err := io.ReadFull(r, buf[16:]); err != nil {
+		return nil, err
+	}
+
+	m := &dbusMessage{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:])}
+	d := dbusDecoder{buf: buf[:16+fieldsLen], off: 12, order: order}
+	raw, err := d.value("a(yv)")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range raw.([]any) {
+		field := f.([]any)
+		v := field[1].(dbusVariant).Value
+		switch field[0].(byte) {
+		case dbusFieldPath:
+			m.Path, _ = v.(string)
+		case dbusFieldInterface:
+			m.Interface, _ = v.(string)
+		case dbusFieldMember:
+			m.Member, _ = v.(string)
+		case dbusFieldErrorName:
+			m.ErrorName, _ = v.(string)
+		case dbusFieldReplySerial:
+			m.ReplySerial, _ = v.(uint32)
+		case dbusFieldDestination:
+			m.Destination, _ = v.(string)
+		case dbusFieldSender:
+			m.Sender, _ = v.(string)
+		case dbusFieldSignature:
+			m.Signature, _ = v.(string)
+		}
+	}
+	if m.Signature != "" {
+		bd := dbusDecoder{buf: buf[headerLen:], order: order}
+		if m.Body, err = bd.values(m.Signature); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// sessionBusAddress is $DBUS_SESSION_BUS_ADDRESS or the systemd default.
+func sessionBusAddress() string {
+	if addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); addr != "" {
+		return addr
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return "unix:path=" + filepath.Join(dir, "bus")
+	}
+	return ""
+}
+
+// dbusSocket picks the first unix transport of a bus address such as
+// "unix:path=/run/user/1000/bus,guid=..." or "unix:abstract=/tmp/dbus-x".
+func dbusSocket(addr string) (string, error) {
+	for _, a := range strings.Split(addr, ";") {
+		rest, ok := strings.CutPrefix(a, "unix:")
+		if !ok {
+			continue
+		}
+		for _, kv := range strings.Split(rest, ",") {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "path":
+				return dbusUnescape(v), nil
+			case "abstract":
+				return "@" + dbusUnescape(v), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("dbus: no unix transport in %q", addr)
+}
+
+// dbusUnescape undoes %XX escapes in address values.
+func dbusUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+type dbusConn struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+	serial  uint32
+
+	callsMu sync.Mutex
+	calls   map[uint32]chan *dbusMessage
+
+	// Signals receives matched signals; they are dropped when nobody
+	// keeps up.
+	Signals chan *dbusMessage
+	done    chan struct{}
+}
+
+// dialDBus connects and authenticates to the bus at addr.
+func dialDBus(addr string) (*dbusConn, error) {
+	path, err := dbusSocket(addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c := &dbusConn{
+		conn:    conn,
+		calls:   make(map[uint32]chan *dbusMessage),
+		Signals: make(chan *dbusMessage, 16),
+		done:    make(chan struct{}),
+	}
+	r := bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(dbusCallTimeout))
+	if err := dbusAuth(conn, r); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	go c.readLoop(r)
+	if _, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", ""); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// dbusAuth runs the EXTERNAL (uid) SASL exchange.
+func dbusAuth(w io.Writer, r *bufio.Reader) error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := io.WriteString(w, "\x00AUTH EXTERNAL "+uid+"\r\n"); err != nil {
+		return err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("dbus: authentication rejected: %s", strings.TrimSpace(line))
+	}
+	_, err = io.WriteString(w, "BEGIN\r\n")
+	return err
+}
+
+func (c *dbusConn) readLoop(r io.Reader) {
+	defer close(c.done)
+	for {
+		m, err := readDBusMessage(r)
+		if err != nil {
+			return
+		}
+		switch m.Type {
+		case dbusMethodReturn, dbusError:
+			c.callsMu.Lock()
+			ch := c.calls[m.ReplySerial]
+			delete(c.calls, m.ReplySerial)
+			c.callsMu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case dbusSignal:
+			select {
+			case c.Signals <- m:
+			default:
+			}
+		}
+	}
+}
+
+// send writes m; a reply to it will be delivered on reply, if not nil.
+func (c *dbusConn) send(m *dbusMessage, reply chan *dbusMessage) (uint32, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.serial++
+	data, err := m.marshal(c.serial)
+	if err != nil {
+		return 0, err
+	}
+	if reply != nil {
+		c.callsMu.Lock()
+		c.calls[c.serial] = reply
+		c.callsMu.Unlock()
+	}
+	_, err = c.conn.Write(data)
+	return c.serial, err
+}
+
+// Call invokes a method and waits for its reply body.
+func (c *dbusConn) Call(dest, path, iface, member, sig string, args ...any) ([]any, error) {
+	ch := make(chan *dbusMessage, 1)
+	serial, err := c.send(&dbusMessage{
+		Type:        dbusMethodCall,
+		Path:        path,
+		Interface:   iface,
+		Member:      member,
+		Destination: dest,
+		Signature:   sig,
+		Body:        args,
+	}, ch)
+	if err != nil {
+		c.forget(serial)
+		return nil, err
+	}
+
+	select {
+	case m := <-ch:
+		if m.Type == dbusError {
+			msg := m.ErrorName
+			if len(m.Body) > 0 {
+				msg += ": " + fmt.Sprint(m.Body[0])
+			}
+			return nil, errors.New(msg)
+		}
+		return m.Body, nil
+	case <-c.done:
+		c.forget(serial)
+		return nil, errors.New("dbus: connection closed")
+	case <-time.After(dbusCallTimeout):
+		c.forget(serial)
+		return nil, fmt.Errorf("dbus: %s.%s timed out", iface, member)
+	}
+}
+
+func (c *dbusConn) forget(serial uint32) {
+	c.callsMu.Lock()
+	delete(c.calls, serial)
+	c.callsMu.Unlock()
+}
+
+// AddMatch subscribes to signals matching rule.
+func (c *dbusConn) AddMatch(rule string) error {
+	_, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", rule)
+	return err
+}
+
+// Done is closed when the connection drops.
+func (c *dbusConn) Done() <-chan struct{} { return c.done }
+
+func (c *dbusConn) Close() error { return c.conn.Close() }
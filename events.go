@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event stream: every change of a window's status or unread flag is
+// published to subscribers of the control API as one NDJSON line. Badges
+// (mode, context, model, cost) ride along in their own fields and never
+// cause an event by themselves. Publishing never blocks the detection
+// loop; a subscriber that falls behind loses events and is told how many
+// on the next one it receives.
+
+// subscriberBuffer is how many events a subscriber may fall behind by.
+const subscriberBuffer = 64
+
+type statusEvent struct {
+	Window      string    `json:"window"`
+	PaneID      string    `json:"pane_id,omitempty"`
+	Agent       string    `json:"agent,omitempty"`
+	OldStatus   string    `json:"old_status"` // status without badges, e.g. "c 🧠"
+	NewStatus   string    `json:"new_status"`
+	Unread      bool      `json:"unread"`
+	Reason      string    `json:"reason"`
+	Name        string    `json:"name,omitempty"` // window name as rendered
+	Mode        string    `json:"mode,omitempty"`
+	ContextLeft *int      `json:"context_left,omitempty"`
+	Result      string    `json:"result,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	CostUSD     *float64  `json:"cost_usd,omitempty"`
+	Message     string    `json:"message,omitempty"`   // agent's last message or completion line
+	Muted       bool      `json:"muted,omitempty"`     // window muted or quiet hours; not pushed
+	Coalesced   []string  `json:"coalesced,omitempty"` // windows of held pushes folded into this one
+	At          time.Time `json:"at"`
+	Dropped     int       `json:"dropped,omitempty"` // events lost before this one
+}
+
+// publishedState is what the last event of a window said.
+type publishedState struct {
+	status string
+	unread bool
+}
+
+// windowPublished is the last published state of each window.
+var windowPublished = make(map[string]publishedState)
+
+type subscriber struct {
+	ch      chan statusEvent
+	dropped int
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = make(map[*subscriber]bool)
+)
+
+// newStatusEvent describes a change of status or unread flag. report is
+// nil when the window no longer runs an agent. Reasons are "unread",
+// "read", "focused", "exit", "gone" or the source that decided the status:
+// "hook", "codex-notify", "transcript", "rollout", "screen" or "process".
+func newStatusEvent(window, oldStatus, newStatus, source string, focused bool, report *windowReport, now time.Time) statusEvent {
+	ev := statusEvent{
+		Window:    window,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Reason:    source,
+		At:        now,
+	}
+	if report == nil {
+		report = windowReports[window] // last known, for pane and agent
+	} else {
+		ev.Unread = report.Unread
+	}
+	if report != nil {
+		ev.PaneID = report.PaneID
+		ev.Agent = report.Agent
+	}
+	if report != nil && newStatus != "" {
+		ev.Name = report.Name
+		ev.Mode, ev.ContextLeft, ev.Result = report.Mode, report.ContextLeft, report.Result
+		ev.Model, ev.CostUSD = report.Model, report.CostUSD
+	}
+
+	wasUnread := strings.Contains(oldStatus, "📬")
+	nowUnread := strings.Contains(newStatus, "📬")
+	switch {
+	case newStatus == "":
+		ev.Reason = "gone"
+	case source == "exit":
+		ev.Reason = "exit"
+	case oldStatus == newStatus && source != "remind":
+		// Only the unread flag changed, e.g. on a ✋ tab.
+		switch {
+		case ev.Unread:
+			ev.Reason = "unread"
+		case focused:
+			ev.Reason = "focused"
+		default:
+			ev.Reason = "read"
+		}
+	case nowUnread && !wasUnread:
+		ev.Reason = "unread"
+	case wasUnread && !nowUnread && isIdleStatus(newStatus):
+		if focused {
+			ev.Reason = "focused"
+		} else {
+			ev.Reason = "read"
+		}
+	}
+	return ev
+}
+
+// trackPublished decides whether a window's status or unread flag changed
+// since its last event. A new status waits for the tab to show it
+// (applied); an unread change that leaves the name alone, like a ✋ tab
+// going unread, is published at once. It returns the previously
+// published status.
+func trackPublished(window, status string, unread, applied bool) (string, bool) {
+	prev, seen := windowPublished[window]
+	if status == prev.status && unread == prev.unread {
+		return "", false
+	}
+	if !applied && !(seen && status == prev.status) {
+		return "", false
+	}
+	windowPublished[window] = publishedState{status: status, unread: unread}
+	return prev.status, true
+}
+
+func subscribe() *subscriber {
+	sub := &subscriber{ch: make(chan statusEvent, subscriberBuffer)}
+	subscribersMu.Lock()
+	subscribers[sub] = true
+	subscribersMu.Unlock()
+	return sub
+}
+
+func unsubscribe(sub *subscriber) {
+	subscribersMu.Lock()
+	delete(subscribers, sub)
+	subscribersMu.Unlock()
+}
+
+// publishEvent hands ev to every subscriber without blocking.
+func publishEvent(ev statusEvent) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for sub := range subscribers {
+		e := ev
+		e.Dropped = sub.dropped
+		select {
+		case sub.ch <- e:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// streamEvents answers a subscribe request: an ok line, then events until
+// the client hangs up.
+func streamEvents(conn net.Conn, enc *json.Encoder) {
+	sub := subscribe()
+	defer unsubscribe(sub)
+	if err := enc.Encode(apiResponse{OK: true}); err != nil {
+		return
+	}
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+	for {
+		select {
+		case ev := <-sub.ch:
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// subscribeAPI copies the daemon's event stream to w until it ends.
+func subscribeAPI(path string, w io.Writer) error {
+	if err := checkPrivateDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("daemon not reachable: %w", err)
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return fmt.Errorf("daemon not reachable: %w", err)
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(apiRequest{Method: "subscribe"}); err != nil {
+		return err
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	var resp apiResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewStatusEvent_Reason(t *testing.T) {
+	now := time.Now()
+	report := &windowReport{PaneID: "%4", Agent: "claude", Unread: true}
+	tests := []struct {
+		name     string
+		old, new string
+		source   string
+		focused  bool
+		report   *windowReport
+		want     string
+	}{
+		{"working", "c 💤", "c 🧠", "transcript", false, report, "transcript"},
+		{"completed unfocused", "c 🧠", "c 📬", "hook", false, report, "unread"},
+		{"read via api", "c 📬", "c 💤", "screen", false, report, "read"},
+		{"read by focusing", "c 📬", "c 💤", "screen", true, report, "focused"},
+		{"back to work from unread", "c 📬", "c 🧠", "hook", false, report, "hook"},
+		{"exited", "c 💤", "c 🚪", "exit", false, report, "exit"},
+		{"agent gone", "c 💤", "", "process", false, nil, "gone"},
+		{"unread without a new status", "c ✋", "c ✋", "screen", false, report, "unread"},
+		{"read without a new status", "c ✋", "c ✋", "screen", true, &windowReport{}, "focused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := newStatusEvent("w:1", tt.old, tt.new, tt.source, tt.focused, tt.report, now)
+			if ev.Reason != tt.want {
+				t.Errorf("reason = %q, want %q", ev.Reason, tt.want)
+			}
+			if ev.OldStatus != tt.old || ev.NewStatus != tt.new || !ev.At.Equal(now) {
+				t.Errorf("unexpected event %+v", ev)
+			}
+		})
+	}
+}
+
+func TestNewStatusEvent_GoneUsesLastReport(t *testing.T) {
+	seedReports(t, &windowReport{Window: "ev:1", PaneID: "%9", Agent: "codex", Unread: true})
+	ev := newStatusEvent("ev:1", "x 📬", "", "process", false, nil, time.Now())
+	if ev.PaneID != "%9" || ev.Agent != "codex" || ev.Unread {
+		t.Errorf("gone event = %+v", ev)
+	}
+}
+
+func TestPublishEvent_SlowSubscriberDrops(t *testing.T) {
+	sub := subscribe()
+	defer unsubscribe(sub)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer+5; i++ {
+			publishEvent(statusEvent{Window: "slow:1"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishEvent blocked on a full subscriber")
+	}
+
+	for i := 0; i < subscriberBuffer; i++ {
+		if ev := <-sub.ch; ev.Dropped != 0 {
+			t.Fatalf("buffered event %d reports %d dropped", i, ev.Dropped)
+		}
+	}
+	publishEvent(statusEvent{Window: "slow:1"})
+	if ev := <-sub.ch; ev.Dropped != 5 {
+		t.Errorf("dropped = %d, want 5", ev.Dropped)
+	}
+	publishEvent(statusEvent{Window: "slow:1"})
+	if ev := <-sub.ch; ev.Dropped != 0 {
+		t.Errorf("dropped count should reset, got %d", ev.Dropped)
+	}
+}
+
+func TestSubscribe_Stream(t *testing.T) {
+	path := filepath.Join(privateTempDir(t), "daemon.sock")
+	go serveAPI(path)
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	json.NewEncoder(conn).Encode(apiRequest{Method: "subscribe"})
+	r := bufio.NewReader(conn)
+	var resp apiResponse
+	line, _ := r.ReadBytes('\n')
+	if json.Unmarshal(line, &resp) != nil || !resp.OK {
+		t.Fatalf("subscribe response = %q", line)
+	}
+
+	publishEvent(statusEvent{Window: "sub:1", OldStatus: "c 🧠", NewStatus: "c 📬", Reason: "unread", Unread: true})
+	var ev statusEvent
+	line, _ = r.ReadBytes('\n')
+	if err := json.Unmarshal(line, &ev); err != nil {
+		t.Fatalf("event line %q: %v", line, err)
+	}
+	if ev.Window != "sub:1" || ev.NewStatus != "c 📬" || ev.Reason != "unread" || !ev.Unread {
+		t.Errorf("event = %+v", ev)
+	}
+}
+
+func TestTrackPublished(t *testing.T) {
+	window := "pub:1"
+	defer delete(windowPublished, window)
+
+	steps := []struct {
+		name    string
+		status  string
+		unread  bool
+		applied bool
+		want    bool
+		wantOld string
+	}{
+		{"first tab", "c 🧠", false, true, true, ""},
+		{"badge tick renames the tab", "c 🧠", false, true, false, ""},
+		{"new status not shown yet", "c 💤", false, false, false, ""},
+		{"new status shown", "c 💤", false, true, true, "c 🧠"},
+		{"approval prompt", "c ✋", false, true, true, "c 💤"},
+		{"unread without a new name", "c ✋", true, false, true, "c ✋"},
+		{"unchanged", "c ✋", true, true, false, ""},
+	}
+	for _, st := range steps {
+		old, ok := trackPublished(window, st.status, st.unread, st.applied)
+		if ok != st.want || old != st.wantOld {
+			t.Errorf("%s: trackPublished() = (%q, %v), want (%q, %v)", st.name, old, ok, st.wantOld, st.want)
+		}
+	}
+}
+
+func TestNewStatusEvent_Badges(t *testing.T) {
+	left := 8
+	report := &windowReport{Name: "c 📬 ⚠️ opus", ContextLeft: &left, Model: "opus", Unread: true}
+	ev := newStatusEvent("w:1", "c 🧠", "c 📬", "hook", false, report, time.Now())
+	if ev.NewStatus != "c 📬" || ev.Name != report.Name || ev.ContextLeft == nil || *ev.ContextLeft != 8 || ev.Model != "opus" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
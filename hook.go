@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Claude Code hooks: Claude runs `tmux-ai-status hook` on lifecycle events
+// with a JSON payload on stdin. The event is stored for the calling pane
+// ($TMUX_PANE) and preferred over pane scraping while it is fresh.
+
+// hookEvents are the Claude Code events the installer subscribes to.
+var hookEvents = []string{
+	"UserPromptSubmit",
+	"PreToolUse",
+	"PostToolUse",
+	"Notification",
+	"Stop",
+	"SubagentStop",
+}
+
+// hookEvent is what `hook` records for a pane.
+type hookEvent struct {
+	Event     string    `json:"event"`
+	Tool      string    `json:"tool,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// hookPayload is the subset of Claude's hook input we use.
+type hookPayload struct {
+	Event     string `json:"hook_event_name"`
+	SessionID string `json:"session_id"`
+	ToolName  string `json:"tool_name"`
+	Message   string `json:"message"`
+}
+
+func runHook(args []string) int {
+	if len(args) > 0 && args[0] == "install" {
+		return runHookInstall(args[1:])
+	}
+	// Hooks must stay silent on stdout (UserPromptSubmit output is added
+	// to Claude's context) and must never exit 2, which blocks the action.
+	if err := recordHook(os.Stdin, os.Getenv("TMUX_PANE"), time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status hook: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func recordHook(r io.Reader, paneID string, now time.Time) error {
+	if paneID == "" {
+		return nil // not running inside tmux
+	}
+	var p hookPayload
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	if p.Event == "" {
+		return errors.New("payload has no hook_event_name")
+	}
+	return writeState(paneStatePath(paneID, "hook"), hookEvent{
+		Event:     p.Event,
+		Tool:      p.ToolName,
+		Message:   p.Message,
+		SessionID: p.SessionID,
+		At:        now,
+	})
+}
+
+func readHookEvent(paneID string) *hookEvent {
+	if paneID == "" {
+		return nil
+	}
+	var ev hookEvent
+	if ok, err := readState(paneStatePath(paneID, "hook"), &ev); !ok || err != nil {
+		return nil
+	}
+	return &ev
+}
+
+// applyHookEvent lets a fresh hook event override the scraped status of a
+// Claude pane. It also returns a completion signature for Stop and
+// Notification events so every new one counts as an unread event; the
+// signature outlives freshness so an ageing event doesn't look new to
+// shouldMarkUnread when pane signatures would take over.
+func applyHookEvent(status string, ev *hookEvent, now time.Time) (string, string) {
+	if ev == nil || !strings.HasPrefix(status, "c ") {
+		return status, ""
+	}
+	sig := ""
+	if ev.Event == "Stop" || ev.Event == "Notification" {
+		sig = "hook:" + ev.Event + ":" + ev.At.Format(time.RFC3339Nano)
+	}
+	if now.Sub(ev.At) > cfg.HookMaxAge.Duration {
+		return status, sig
+	}
+	icon := strings.TrimPrefix(status, "c ")
+	// The pane is still the better witness for states hooks don't report:
+	// Esc interrupts and compaction fire no event, exits are tracked separately.
+	if icon == "✋" || icon == "🗜️" || strings.Contains(icon, "🚪") {
+		return status, sig
+	}
+
+	switch ev.Event {
+	case "Stop", "Notification":
+		return "c 💤", sig
+	case "UserPromptSubmit", "PreToolUse", "PostToolUse", "SubagentStop":
+		if icon == "💤" {
+			return "c 🧠", ""
+		}
+	}
+	return status, sig
+}
+
+// runHookInstall adds `tmux-ai-status hook` to Claude's settings.json for
+// every event in hookEvents, keeping existing settings and hooks.
+func runHookInstall(args []string) int {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tmux-ai-status: %v\n", err)
+			return 1
+		}
+		path = filepath.Join(home, ".claude", "settings.json")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status: %v\n", err)
+		return 1
+	}
+	added, err := installClaudeHooks(path, shellWord(exe)+" hook")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status: %v\n", err)
+		return 1
+	}
+	if added == 0 {
+		fmt.Printf("%s already has the hooks\n", path)
+	} else {
+		fmt.Printf("added %d hooks to %s\n", added, path)
+	}
+	return 0
+}
+
+// installClaudeHooks edits only the hooks it adds; the rest of the
+// file keeps its keys in their original order.
+func installClaudeHooks(path, command string) (int, error) {
+	settings := &jsonObject{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, settings); err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return 0, err
+	}
+
+	hooks := &jsonObject{}
+	if raw, ok := settings.get("hooks"); ok {
+		if err := json.Unmarshal(raw, hooks); err != nil {
+			return 0, fmt.Errorf("%s: hooks: %w", path, err)
+		}
+	}
+	added := 0
+	for _, event := range hookEvents {
+		var groups []json.RawMessage
+		if raw, ok := hooks.get(event); ok {
+			if err := json.Unmarshal(raw, &groups); err != nil {
+				return 0, fmt.Errorf("%s: hooks.%s: %w", path, event, err)
+			}
+		}
+		if hasHookCommand(groups, command) {
+			continue
+		}
+		group := map[string]any{
+			"hooks": []any{map[string]any{"type": "command", "command": command}},
+		}
+		if event == "PreToolUse" || event == "PostToolUse" {
+			group["matcher"] = "*"
+		}
+		raw, err := json.Marshal(group)
+		if err != nil {
+			return 0, err
+		}
+		groups = append(groups, raw)
+		if raw, err = json.Marshal(groups); err != nil {
+			return 0, err
+		}
+		hooks.set(event, raw)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	raw, err := json.Marshal(hooks)
+	if err != nil {
+		return 0, err
+	}
+	settings.set("hooks", raw)
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	return added, os.WriteFile(path, append(out, '\n'), 0644)
+}
+
+func hasHookCommand(groups []json.RawMessage, command string) bool {
+	for _, g := range groups {
+		var group struct {
+			Hooks []struct {
+				Command string `json:"command"`
+			} `json:"hooks"`
+		}
+		json.Unmarshal(g, &group)
+		for _, entry := range group.Hooks {
+			if entry.Command == command {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonObject is a JSON object that keeps its keys in order and its
+// values as written.
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o *jsonObject) get(key string) (json.RawMessage, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+func (o *jsonObject) set(key string, v json.RawMessage) {
+	if o.values == nil {
+		o.values = make(map[string]json.RawMessage)
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		o.set(key, v)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(o.values[k])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordHook(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	now := time.Now()
+
+	payload := `{"session_id":"abc","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`
+	if err := recordHook(strings.NewReader(payload), "%7", now); err != nil {
+		t.Fatalf("recordHook: %v", err)
+	}
+	ev := readHookEvent("%7")
+	if ev == nil {
+		t.Fatal("expected stored event")
+	}
+	if ev.Event != "PreToolUse" || ev.Tool != "Bash" || ev.SessionID != "abc" || !ev.At.Equal(now) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	if readHookEvent("%8") != nil {
+		t.Error("other panes should have no event")
+	}
+}
+
+func TestRecordHook_Errors(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := recordHook(strings.NewReader("{}"), "", time.Now()); err != nil {
+		t.Errorf("outside tmux should be a no-op, got %v", err)
+	}
+	if err := recordHook(strings.NewReader("not json"), "%1", time.Now()); err == nil {
+		t.Error("expected decode error")
+	}
+	if err := recordHook(strings.NewReader(`{"session_id":"x"}`), "%1", time.Now()); err == nil {
+		t.Error("expected error for missing event name")
+	}
+}
+
+func TestApplyHookEvent(t *testing.T) {
+	now := time.Now()
+	fresh := func(event string) *hookEvent { return &hookEvent{Event: event, At: now.Add(-time.Second)} }
+	stale := &hookEvent{Event: "Stop", At: now.Add(-cfg.HookMaxAge.Duration - time.Second)}
+
+	tests := []struct {
+		name    string
+		status  string
+		ev      *hookEvent
+		want    string
+		wantSig bool
+	}{
+		{"stop beats stale spinner", "c 🧠", fresh("Stop"), "c 💤", true},
+		{"stop beats background child", "c 📦", fresh("Stop"), "c 💤", true},
+		{"notification needs attention", "c 🧠", fresh("Notification"), "c 💤", true},
+		{"prompt submit beats idle prompt", "c 💤", fresh("UserPromptSubmit"), "c 🧠", false},
+		{"tool use keeps child icon", "c 🔨", fresh("PreToolUse"), "c 🔨", false},
+		{"interrupt is left to the pane", "c ✋", fresh("PreToolUse"), "c ✋", false},
+		{"stale event is ignored", "c 🧠", stale, "c 🧠", true},
+		{"codex panes are untouched", "x 🧠", fresh("Stop"), "x 🧠", false},
+		{"no event", "c 🧠", nil, "c 🧠", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, sig := applyHookEvent(tt.status, tt.ev, now)
+			if got != tt.want || (sig != "") != tt.wantSig {
+				t.Errorf("applyHookEvent() = (%q, %q), want %q (sig=%v)", got, sig, tt.want, tt.wantSig)
+			}
+		})
+	}
+}
+
+func TestInstallClaudeHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(`{
+  "model": "opus",
+  "hooks": {
+    "Stop": [{"hooks": [{"type": "command", "command": "afplay done.aiff"}]}]
+  }
+}`), 0644)
+
+	added, err := installClaudeHooks(path, "/bin/tmux-ai-status hook")
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if added != len(hookEvents) {
+		t.Errorf("added %d hooks, want %d", added, len(hookEvents))
+	}
+
+	var settings map[string]any
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("settings no longer valid JSON: %v", err)
+	}
+	if settings["model"] != "opus" {
+		t.Error("existing settings should be kept")
+	}
+	stop := settings["hooks"].(map[string]any)["Stop"].([]any)
+	if len(stop) != 2 {
+		t.Errorf("existing Stop hook should be kept alongside ours, got %d groups", len(stop))
+	}
+	pre := settings["hooks"].(map[string]any)["PreToolUse"].([]any)
+	if pre[0].(map[string]any)["matcher"] != "*" {
+		t.Error("tool hooks need a matcher")
+	}
+
+	added, err = installClaudeHooks(path, "/bin/tmux-ai-status hook")
+	if err != nil || added != 0 {
+		t.Errorf("second install should be a no-op, got added=%d err=%v", added, err)
+	}
+}
+
+func TestInstallClaudeHooks_KeepsKeyOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(path, []byte(`{"zeta": 1, "model": "opus", "env": {"B": "1", "A": "2"}}`), 0644)
+
+	if _, err := installClaudeHooks(path, "'/opt/my tools/tmux-ai-status' hook"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+	order := []string{`"zeta"`, `"model"`, `"env"`, `"B"`, `"A"`, `"hooks"`}
+	last := -1
+	for _, k := range order {
+		i := strings.Index(out, k)
+		if i < last {
+			t.Fatalf("key %s moved:\n%s", k, out)
+		}
+		last = i
+	}
+	if !strings.Contains(out, `"command": "'/opt/my tools/tmux-ai-status' hook"`) {
+		t.Errorf("quoted command missing:\n%s", out)
+	}
+}
+
+func TestPrunePaneState(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	for _, id := range []string{"%1", "%2"} {
+		writeState(paneStatePath(id, "hook"), map[string]string{})
+	}
+	listedAt := time.Now().Add(time.Second)
+	prunePaneState(map[string]bool{"%1": true}, listedAt)
+	if _, err := os.Stat(paneStatePath("%1", "hook")); err != nil {
+		t.Error("live pane state should be kept")
+	}
+	if _, err := os.Stat(paneStatePath("%2", "hook")); !os.IsNotExist(err) {
+		t.Error("state of a gone pane should be removed")
+	}
+
+	writeState(paneStatePath("%3", "hook"), map[string]string{})
+	prunePaneState(map[string]bool{"%1": true}, time.Now().Add(-time.Minute))
+	if _, err := os.Stat(paneStatePath("%3", "hook")); err != nil {
+		t.Error("state written after the pane list should be kept")
+	}
+}
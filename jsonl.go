@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// jsonlMaxRead bounds the initial read of a long session log; the
+// current state only depends on the last few records.
+const jsonlMaxRead = 512 * 1024
+
+// jsonlTail follows an append-only JSONL file across polls.
+type jsonlTail struct {
+	path    string
+	offset  int64
+	partial []byte
+}
+
+// read returns the complete lines appended since the last call. restarted
+// reports that the file shrank and is being read from the start again, so
+// any state derived from earlier lines should be dropped.
+func (t *jsonlTail) read() (lines [][]byte, restarted bool, err error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	size := info.Size()
+	if size < t.offset {
+		t.offset, t.partial, restarted = 0, nil, true
+	}
+	skipFirst := false
+	if t.offset == 0 && size > jsonlMaxRead {
+		t.offset = size - jsonlMaxRead
+		skipFirst = true
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, restarted, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, restarted, err
+	}
+	t.offset += int64(len(data))
+
+	buf := append(t.partial, data...)
+	end := bytes.LastIndexByte(buf, '\n')
+	if end < 0 {
+		t.partial = buf
+		return nil, restarted, nil
+	}
+	t.partial = append([]byte(nil), buf[end+1:]...)
+
+	sc := bufio.NewScanner(bytes.NewReader(buf[:end]))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if skipFirst {
+			skipFirst = false // probably cut mid-record
+			continue
+		}
+		lines = append(lines, append([]byte(nil), sc.Bytes()...))
+	}
+	return lines, restarted, sc.Err()
+}
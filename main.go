@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"strconv"
@@ -13,16 +14,21 @@ import (
 
 var (
 	listPanesOutput = func() ([]byte, error) {
-		return exec.Command("tmux", "list-panes", "-a",
-			"-F", "#{session_name}:#{window_index} #{pane_pid} #{window_active}").Output()
+		return exec.Command("tmux", "list-panes", "-a", "-F", strings.Join([]string{
+			"#{session_name}:#{window_index}", "#{pane_pid}", "#{window_active}", "#{pane_id}",
+			"#{window_bell_flag}", "#{pane_title}", "#{pane_current_command}", "#{pane_current_path}",
+			"#{" + paneStatusOption + "}", "#{window_id}", "#{" + remindStyledOption + "}",
+		}, "\t")).Output()
 	}
-	capturePaneOutput = func(window string) ([]byte, error) {
-		return exec.Command("tmux", "capture-pane", "-t", window, "-p").Output()
+	// capturePaneOutput captures one pane, given its id ("%12"), or the
+	// active pane of a window with old list-panes output.
+	capturePaneOutput = func(target string) ([]byte, error) {
+		return exec.Command("tmux", "capture-pane", "-t", target, "-p").Output()
 	}
 )
 
 var (
-	// lastActive tracks when each window was last seen as active.
+	// lastActive tracks when each pane was last seen as active.
 	// Prevents flashing during spinner redraws.
 	lastActive   = make(map[string]time.Time)
 	lastActiveMu sync.Mutex
@@ -45,16 +51,103 @@ type windowState struct {
 const stabilityThreshold = 1 // cycles a new status must hold before applying
 
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+	os.Exit(runDaemon(os.Args[1:]))
+}
+
+func runDaemon(args []string) int {
+	table := false
+	for _, a := range args {
+		switch a {
+		case "--dry-run":
+			dryRun, logDryRun = true, true
+		case "--table":
+			table = true
+		default:
+			fmt.Fprintf(os.Stderr, "usage: tmux-ai-status [--dry-run [--table]]\n")
+			return 2
+		}
+	}
+	if table && !dryRun {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status: --table needs --dry-run\n")
+		return 2
+	}
+
+	c, err := loadConfig(configPath())
+	if err != nil {
+		log.Printf("config: %v (using defaults)", err)
+	}
+	cfg = c
+
+	// An observer must not take the socket from the real daemon, or
+	// push anything.
+	if !dryRun {
+		go func() {
+			if err := serveAPI(apiSocketPath()); err != nil {
+				log.Printf("api: %v", err)
+			}
+		}()
+		go newPusher().run(subscribe())
+	}
+
 	for {
+		daemonMu.Lock()
 		updateAllPanes()
-		time.Sleep(2 * time.Second)
+		if table {
+			printWindowTable(os.Stdout, windowReports, time.Now())
+		}
+		daemonMu.Unlock()
+		select {
+		case <-time.After(2 * time.Second):
+		case <-wake:
+		}
 	}
 }
 
+func runCommand(args []string) int {
+	switch args[0] {
+	case "hook":
+		return runHook(args[1:])
+	case "codex-notify":
+		return runCodexNotify(args[1:])
+	case "statusline":
+		return runStatusline(args[1:])
+	case "ctl":
+		return runCtl(args[1:])
+	case "status":
+		return runStatus(args[1:])
+	case "explain":
+		return runExplain(args[1:])
+	case "push-test":
+		return runPushTest(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "usage: tmux-ai-status [hook [install [settings.json]] | codex-notify [install [config.toml] | PAYLOAD] | statusline | ctl METHOD [ARGS] | status [--json] | explain TARGET | push-test [NAME]]\n")
+	return 2
+}
+
 type paneInfo struct {
 	window  string
 	pid     int
 	focused bool
+	paneID  string // "%12"; empty with old list-panes output
+	bell    bool   // window_bell_flag
+	title   string // set by the program with OSC 0/2
+	command string // pane_current_command
+	path    string // pane_current_path
+	option  string // @ai_pane_status as tmux has it
+	winID   string // "@3"; unlike window, stable across renumbering
+	styled  bool   // the window carries remindStyledOption
+}
+
+// target is what to capture for the pane: its id, or with old list-panes
+// output that has none, the window.
+func (p paneInfo) target() string {
+	if p.paneID != "" {
+		return p.paneID
+	}
+	return p.window
 }
 
 // Unread tracking: detect when agent finishes work while user isn't looking.
@@ -63,10 +156,35 @@ var (
 	windowSeen       = make(map[string]bool)
 	windowPromptSig  = make(map[string]string)
 	windowDoneSig    = make(map[string]string)
-	windowActiveSig  = make(map[string]string)
-	windowActiveAt   = make(map[string]time.Time)
 )
 
+// Stale-marker tracking: the active marker each pane has shown, and since
+// when.
+var (
+	paneActiveSig = make(map[string]string)
+	paneActiveAt  = make(map[string]time.Time)
+)
+
+// Exit tracking: remember the agent behind each window so that its exit
+// can be shown for cfg.ExitLinger instead of silently reverting the name.
+var (
+	windowLastStatus = make(map[string]string)
+	windowAgent      = make(map[string]agentProc)
+	windowExit       = make(map[string]*exitRecord)
+)
+
+type agentProc struct {
+	pid    int
+	name   string // "claude" or "codex"
+	paneID string
+}
+
+type exitRecord struct {
+	agent agentProc // the process that exited
+	last  string    // last raw status seen while the agent was alive
+	at    time.Time // when the exit was noticed
+}
+
 func listPanes() []paneInfo {
 	out, err := listPanesOutput()
 	if err != nil {
@@ -75,7 +193,12 @@ func listPanes() []paneInfo {
 	var panes []paneInfo
 	sc := bufio.NewScanner(strings.NewReader(string(out)))
 	for sc.Scan() {
-		fields := strings.Fields(sc.Text())
+		// Tab-separated since titles and paths contain spaces; plain
+		// space-separated lines carry only the first four fields.
+		fields := strings.Split(sc.Text(), "\t")
+		if len(fields) == 1 {
+			fields = strings.Fields(sc.Text())
+		}
 		if len(fields) < 3 {
 			continue
 		}
@@ -83,11 +206,30 @@ func listPanes() []paneInfo {
 		if err != nil {
 			continue
 		}
-		panes = append(panes, paneInfo{
+		pane := paneInfo{
 			window:  fields[0],
 			pid:     pid,
 			focused: fields[2] == "1",
-		})
+		}
+		if len(fields) > 3 {
+			pane.paneID = fields[3]
+		}
+		if len(fields) > 7 {
+			pane.bell = fields[4] == "1"
+			pane.title = fields[5]
+			pane.command = fields[6]
+			pane.path = fields[7]
+		}
+		if len(fields) > 8 {
+			pane.option = fields[8]
+		}
+		if len(fields) > 9 {
+			pane.winID = fields[9]
+		}
+		if len(fields) > 10 {
+			pane.styled = fields[10] == "1"
+		}
+		panes = append(panes, pane)
 	}
 	return panes
 }
@@ -95,74 +237,291 @@ func listPanes() []paneInfo {
 type paneCapture struct {
 	content string
 	ok      bool
+	motion  int // observeMotion result for this capture
 }
 
-func getPaneContent(window string, cache map[string]*paneCapture) (string, bool) {
-	if c, ok := cache[window]; ok {
+func getPaneContent(pane string, cache map[string]*paneCapture) (string, bool) {
+	if c, ok := cache[pane]; ok {
 		return c.content, c.ok
 	}
 
-	out, err := capturePaneOutput(window)
+	out, err := capturePaneOutput(pane)
 	if err != nil {
-		cache[window] = &paneCapture{ok: false}
+		cache[pane] = &paneCapture{ok: false}
 		return "", false
 	}
 
 	content := string(out)
-	cache[window] = &paneCapture{content: content, ok: true}
+	cache[pane] = &paneCapture{content: content, ok: true, motion: observeMotion(pane, content)}
 	return content, true
 }
 
 func updateAllPanes() {
+	listedAt := time.Now()
 	panes := listPanes()
 	if len(panes) == 0 {
 		return
 	}
+	if !dryRun {
+		live := make(map[string]bool)
+		for _, p := range panes {
+			live[p.paneID] = true
+		}
+		if !live[""] { // old tmux without pane ids
+			prunePaneState(live, listedAt)
+		}
+	}
 
 	childMap := buildChildMap()
 	seenWindows := make(map[string]bool)
+	seenPanes := make(map[string]bool) // agent panes
+	// seenTargets holds every pane's capture target, see paneInfo.target.
+	seenTargets := make(map[string]bool)
 	paneCache := make(map[string]*paneCapture)
 
 	// Group panes by window — pick the most significant status per window.
 	type windowSummary struct {
-		status  string
-		focused bool
+		status   string
+		focused  bool
+		footer   paneFooter
+		agent    agentProc
+		task     childTask
+		eventSig string // completion signature reported by the agent itself
+		source   string // what decided the status, for subscribers
+		message  string // last thing the agent said, for notifications
+		// permission is set while the agent waits for an approval.
+		permission bool
+		bell       bool   // an agent pane rang the terminal bell
+		titleFrom  string // an agent pane's previous title, when it changed
+		path       string // pane_current_path
+		pane       string // capture target of the chosen pane
+		winID      string // tmux window id
+		styled     bool   // restyled by a reminder
 	}
 	summaries := make(map[string]*windowSummary)
+	agentPanes := make(map[string][]paneEntry)
+	var panesOut []*paneReport
+	traces := make(map[string]*decisionTrace)
+	traced := make(map[string]string)
+	defer func() {
+		curTrace = nil
+		windowTraces, tracedPanes = traces, traced
+	}()
 
 	for _, p := range panes {
 		seenWindows[p.window] = true
-		rawStatus := getStatus(p.window, p.pid, childMap, paneCache)
+		target := p.target()
+		seenTargets[target] = true
+		if !dryRun {
+			adoptPaneOption(p.paneID, p.option)
+		}
+		if traces[p.window] == nil {
+			traces[p.window] = &decisionTrace{}
+		}
+		curTrace = traces[p.window]
+		traced[p.paneID] = p.window
+		tracef("pane %s (shell pid %d, focused %v, running %s)", p.paneID, p.pid, p.focused, p.command)
+		agentPID, agentName := findAgent(p.pid, childMap)
+		if agentPID == 0 {
+			tracef("agent: no claude/codex process under the shell")
+		} else {
+			tracef("agent: %s pid %d: %s", agentName, agentPID, readCmdline(agentPID))
+		}
+		rawStatus, task := agentStatus(target, agentPID, agentName, childMap, paneCache)
+		if agentPID != 0 {
+			tracePaneLines(target, paneCache)
+			traceTimers(target, time.Now())
+			tracef("detected: %q", rawStatus)
+		}
+		// Sources the agent writes itself beat pane text:
+		// hooks/notify > session logs > scraping.
+		eventSig := ""
+		source := "process"
+		if paneCache[target] != nil {
+			source = "screen" // pane text was consulted
+		}
+		scraped := rawStatus
+		// An approval prompt on screen outranks session logs, which
+		// already record the tool the agent is asking about.
+		prompt := strings.HasSuffix(scraped, "💤") && panePermission(target, paneCache)
+		message := ""
+		var session *sessionInfo
+		switch agentName {
+		case "claude":
+			session = readSessionInfo(p.paneID, procStartTime(agentPID))
+			path := ""
+			if session != nil {
+				path = session.TranscriptPath
+			}
+			if path == "" {
+				path = findClaudeTranscript(agentPID)
+			}
+			transcript := pollTranscript(p.paneID, path)
+			rawStatus, eventSig = applyTranscript(rawStatus, transcript, time.Now(), prompt)
+			if rawStatus != scraped || eventSig != "" {
+				source = "transcript"
+			}
+			if transcript != nil {
+				message = transcript.lastMessage
+			}
+		case "codex":
+			rollout := pollRollout(p.paneID, agentPID, time.Now())
+			rawStatus, eventSig = applyRollout(rawStatus, rollout, time.Now(), prompt)
+			if rawStatus != scraped || eventSig != "" {
+				source = "rollout"
+			}
+		}
+		beforeHook := rawStatus
+		hook := readHookEvent(p.paneID)
+		rawStatus, hookSig := applyHookEvent(rawStatus, hook, time.Now())
+		permission := false
+		if hookSig != "" {
+			eventSig = hookSig
+			if hook.Message != "" {
+				message = hook.Message
+			}
+			permission = hook.Event == "Notification" &&
+				strings.Contains(strings.ToLower(hook.Message), "permission")
+		}
+		if hookSig != "" || rawStatus != beforeHook {
+			source = "hook"
+		}
+		turn := readCodexTurn(p.paneID)
+		if sig := codexTurnSignature(rawStatus, turn); sig != "" {
+			eventSig = sig
+			source = "codex-notify"
+			message = turn.Message
+		}
+		if strings.HasSuffix(rawStatus, "💤") && !permission {
+			permission = panePermission(target, paneCache)
+		}
+		if rawStatus != "" {
+			tracef("source: %s, status %q, completion signature %q", source, rawStatus, eventSig)
+			tracef("pane title %q, bell %v", p.title, p.bell)
+		}
+		footer := noFooter
+		agent := agentProc{}
+		titleFrom := ""
+		if rawStatus != "" {
+			footer = paneFooterInfo(target, paneCache)
+			// The footer shows the current mode, which shift+tab may
+			// have changed since launch.
+			if !footer.modeShown {
+				footer.mode = classifyCmdlineMode(readArgv(agentPID))
+			}
+			if session != nil {
+				footer.model = session.Model
+				footer.costUSD = session.CostUSD
+			}
+			agent = agentProc{
+				pid:    agentPID,
+				name:   agentName,
+				paneID: p.paneID,
+			}
+			seenPanes[p.paneID] = true
+			titleFrom = trackPaneTitle(p.paneID, p.title)
+			panesOut = append(panesOut, newPaneReport(p, agent, rawStatus, task, time.Now()))
+			if p.paneID != "" {
+				agentPanes[p.window] = append(agentPanes[p.window], paneEntry{
+					paneID:   p.paneID,
+					status:   rawStatus,
+					eventSig: eventSig,
+				})
+			}
+		}
 		prev, exists := summaries[p.window]
 		if !exists {
-			summaries[p.window] = &windowSummary{status: rawStatus, focused: p.focused}
+			summaries[p.window] = &windowSummary{
+				status:     rawStatus,
+				focused:    p.focused,
+				footer:     footer,
+				agent:      agent,
+				task:       task,
+				eventSig:   eventSig,
+				source:     source,
+				message:    message,
+				permission: permission,
+				path:       p.path,
+				pane:       target,
+				winID:      p.winID,
+				styled:     p.styled,
+			}
+			if rawStatus != "" {
+				summaries[p.window].bell = p.bell
+				summaries[p.window].titleFrom = titleFrom
+			}
 		} else {
 			prev.focused = prev.focused || p.focused
 			if statusPriority(rawStatus) > statusPriority(prev.status) {
 				prev.status = rawStatus
+				prev.footer = footer
+				prev.agent = agent
+				prev.task = task
+				prev.eventSig = eventSig
+				prev.source = source
+				prev.message = message
+				prev.permission = permission
+				prev.path = p.path
+				prev.pane = target
+			}
+			prev.bell = prev.bell || (rawStatus != "" && p.bell)
+			if titleFrom != "" {
+				prev.titleFrom = titleFrom
 			}
 		}
 	}
 
 	// Apply unread logic per window, then set status.
+	now := time.Now()
+	if cfg.Notify || len(cfg.Push) > 0 {
+		presence = readPresence(now)
+	}
+	windowMute = readWindowMutes()
+	reports := make(map[string]*windowReport)
 	for window, s := range summaries {
+		curTrace = traces[window]
+		tracef("window %s: chose %q", window, s.status)
+		exit := trackAgentExit(window, s.status, s.agent, now)
+		if exit != nil {
+			s.status = exitStatus(exit, false)
+			s.footer = noFooter
+			s.source = "exit"
+			tracef("exit: agent exited %s ago, showing %q", now.Sub(exit.at).Round(time.Second), s.status)
+		}
+		s.footer.result = trackTaskResult(s.pane, s.task, paneCache, now)
 		rawStatus := s.status
 		focused := s.focused
+		wasUnread := isUnread(window)
+		muted := muteLevel(window, now)
+		// An agent that exits while nobody is looking deserves attention.
+		if exit != nil && exit.at.Equal(now) && !focused && !isSnoozed(window, now) && muted != muteAll {
+			markUnread(window)
+		}
 		wasWorking := windowWasWorking[window]
 		isWorking := isWorkingStatus(rawStatus)
 		seenBefore := windowSeen[window]
 		promptSig := ""
 		doneSig := ""
+		paneDone := ""
 		if !isWorking && rawStatus != "" {
-			promptSig, doneSig = paneSignals(window, paneCache)
+			promptSig, doneSig = paneSignals(s.pane, paneCache)
+			paneDone = doneSig
+			// Hooks and notify programs report completion exactly;
+			// prefer them over "─ Worked for"/"Done." lines.
+			if s.eventSig != "" {
+				doneSig = s.eventSig
+			}
 		}
 		prevPromptSig := windowPromptSig[window]
 		prevDoneSig := windowDoneSig[window]
+		bellRang := s.bell && !windowBell[window]
+		titleChanged := seenBefore && !wasWorking && s.titleFrom != ""
+		windowBell[window] = s.bell
 
 		// Mark unread only for meaningful events:
 		// - working -> idle completion while unfocused
 		// - new completion/prompt signature after initial baseline
-		if shouldMarkUnread(
+		mark, why := unreadDecision(
 			wasWorking,
 			focused,
 			isWorking,
@@ -172,7 +531,28 @@ func updateAllPanes() {
 			prevPromptSig,
 			doneSig,
 			prevDoneSig,
-		) {
+		)
+		// The agent's own bell and title are strong signals that the
+		// screen signatures may miss.
+		if !mark && !focused && !isWorking && rawStatus != "" {
+			switch {
+			case bellRang:
+				mark, why = true, "terminal bell"
+			case titleChanged:
+				mark, why = true, fmt.Sprintf("pane title changed from %q", s.titleFrom)
+			}
+		}
+		tracef("unread rule: %s (mark %v); prompt %q (was %q), completion %q (was %q)",
+			why, mark, promptSig, prevPromptSig, doneSig, prevDoneSig)
+		if mark && isSnoozed(window, now) {
+			tracef("unread: snoozed until %s", windowSnooze[window].Format(time.TimeOnly))
+			mark = false
+		}
+		if mark && muted == muteAll {
+			tracef("unread: muted")
+			mark = false
+		}
+		if mark {
 			markUnread(window)
 		}
 		// User focused the window → clear unread
@@ -183,28 +563,182 @@ func updateAllPanes() {
 		if isWorking {
 			clearUnread(window)
 		}
+		// In multi-pane mode a pane that finished beside a working one
+		// keeps the window unread.
+		entries := agentPanes[window]
+		if cfg.MultiPane {
+			canMark := !isSnoozed(window, now) && muted != muteAll
+			for _, e := range entries {
+				if trackPaneUnread(e, focused, canMark) {
+					if !wasUnread && !isUnread(window) {
+						tracef("unread: pane %s finished", e.paneID)
+					}
+					markUnread(window)
+				}
+			}
+			for _, e := range entries {
+				unread := paneUnread[e.paneID] || len(entries) == 1 && isUnread(window)
+				setPaneOption(e.paneID, paneStatusText(e.status, unread))
+			}
+		}
 
 		windowWasWorking[window] = isWorking
 		windowSeen[window] = true
 		windowPromptSig[window] = promptSig
 		windowDoneSig[window] = doneSig
 
-		// Replace 💤 with 📬 if unread
+		// Show 📬 if unread
 		effectiveStatus := rawStatus
 		if !isWorking && rawStatus != "" && isUnread(window) {
-			if strings.HasSuffix(rawStatus, "💤") {
-				effectiveStatus = strings.TrimSuffix(rawStatus, "💤") + "📬"
+			if exit != nil {
+				effectiveStatus = exitStatus(exit, true)
+			} else {
+				effectiveStatus = unreadStatus(rawStatus)
 			}
 		}
+		footer := s.footer
+		if cfg.MultiPane && len(entries) > 1 && exit == nil {
+			effectiveStatus = multiPaneStatus(entries, func(id string) bool { return paneUnread[id] })
+			// Badges would be those of one pane only; @ai_pane_status
+			// and the API carry each pane's own.
+			footer = noFooter
+		}
+		plainStatus := effectiveStatus
+		if effectiveStatus != "" {
+			effectiveStatus = renderWindowName(effectiveStatus, footer)
+		}
 
-		setWindowStatus(window, effectiveStatus)
+		tracef("unread %v; window name %q", isUnread(window), effectiveStatus)
+		old, applied := setWindowStatus(window, effectiveStatus)
+		var report *windowReport
+		if rawStatus != "" {
+			report = newWindowReport(window, s.focused, rawStatus, effectiveStatus, s.footer, s.agent, s.task, now)
+			report.Muted = muted
+			reports[window] = report
+		}
+		// What the agent last said, for notifications; stale while working.
+		line := ""
+		if !isWorking {
+			line = completionLine(s.message)
+			if line == "" {
+				line = completionLine(paneDone)
+			}
+		}
+		cls := statusClass(rawStatus, exit, s.permission)
+		agentNow := s.agent
+		if exit != nil {
+			agentNow = exit.agent
+		}
+		prevClass, seenClass := windowClass[window]
+		cwd := prevClass.cwd // a dead agent's cwd can't be read
+		if s.agent.pid != 0 && len(cfg.OnTransition) > 0 {
+			cwd = readCwd(s.agent.pid)
+		}
+		if cwd == "" {
+			cwd = s.path
+		}
+		if seenClass && prevClass.class != cls && len(cfg.OnTransition) > 0 {
+			tracef("transition: %s -> %s (muted %q)", prevClass.class, cls, muted)
+			fireTransition(cfg.OnTransition, transition{
+				window:    window,
+				windowID:  s.winID,
+				paneID:    agentNow.paneID,
+				agent:     agentNow.name,
+				oldStatus: prevClass.status,
+				newStatus: rawStatus,
+				from:      prevClass.class,
+				to:        cls,
+				cwd:       cwd,
+				message:   line,
+				muted:     muted != muteOff,
+			}, cfg.TransitionConcurrency)
+		}
+		windowClass[window] = classState{class: cls, status: rawStatus, cwd: cwd}
+		if cfg.TmuxAlert.enabled() && muted == muteOff && report != nil {
+			reason := ""
+			switch {
+			case cls == classPermission && seenClass && prevClass.class != classPermission:
+				reason = "permission"
+			case report.Unread && !wasUnread:
+				reason = "unread"
+			}
+			if reason != "" {
+				tracef("tmux alert: %s", reason)
+				ev := newStatusEvent(window, prevClass.status, plainStatus, reason, focused, report, now)
+				ev.Reason, ev.Message = reason, line
+				fireTmuxAlert(cfg.TmuxAlert, ev)
+			}
+		}
+		if !seenBefore && s.styled {
+			setWindowStyle(window, "") // left over from a previous daemon
+		}
+		remind := trackReminder(window, report != nil && report.Unread && !isSnoozed(window, now), now)
+		if remind.escalate {
+			tracef("remind: unread for %s, escalating", remind.waiting.Round(time.Second))
+			setWindowStyle(window, cfg.Remind.Style)
+		} else if remind.restore {
+			setWindowStyle(window, "")
+		}
+		if remind.remind {
+			tracef("remind: unread for %s, reminding", remind.waiting.Round(time.Second))
+			if cfg.Notify && !dryRun && muted == muteOff && presence.wantsDesktop() {
+				desktopNotifier.queue(unreadNotice{
+					window:  window,
+					name:    effectiveStatus,
+					agent:   report.Agent,
+					line:    line,
+					waiting: remind.waiting,
+				}, cfg.NotifyCoalesce.Duration)
+			}
+			ev := newStatusEvent(window, plainStatus, plainStatus, "remind", focused, report, now)
+			ev.Message = line
+			ev.Muted = muted != muteOff
+			publishEvent(ev)
+		}
+		if muted != muteOff && report != nil && report.Unread && !wasUnread {
+			tracef("notify: muted (%s)", muted)
+		}
+		if cfg.Notify && !dryRun && muted == muteOff && report != nil && report.Unread && !wasUnread && presence.wantsDesktop() {
+			desktopNotifier.queue(unreadNotice{
+				window: window,
+				name:   effectiveStatus,
+				agent:  report.Agent,
+				line:   line,
+			}, cfg.NotifyCoalesce.Duration)
+		}
+		if applied && logDryRun {
+			reason := newStatusEvent(window, old, effectiveStatus, s.source, focused, report, now).Reason
+			log.Printf("dry-run: would run %s (%s)", shellQuote(tmuxStatusArgs(window, effectiveStatus)), reason)
+		}
+		if prev, ok := trackPublished(window, plainStatus, report != nil && report.Unread, applied); ok {
+			ev := newStatusEvent(window, prev, plainStatus, s.source, focused, report, now)
+			ev.Message = line
+			ev.Muted = muted != muteOff
+			publishEvent(ev)
+		}
+	}
+	windowReports = reports
+	sessions := make(map[string]bool)
+	for w := range seenWindows {
+		sessions[windowSession(w)] = true
+	}
+	updateSummaries(reports, windowClass, sessions)
+	for _, pr := range panesOut {
+		if wr := reports[pr.Window]; wr != nil {
+			pr.EffectiveStatus = wr.Name
+			pr.Unread = wr.Unread
+			if cfg.MultiPane && len(agentPanes[pr.Window]) > 1 {
+				pr.Unread = paneUnread[pr.PaneID]
+			}
+		}
 	}
+	paneReports = panesOut
 
 	// Clean up stale entries
 	lastActiveMu.Lock()
-	for w := range lastActive {
-		if !seenWindows[w] {
-			delete(lastActive, w)
+	for id := range lastActive {
+		if !seenTargets[id] {
+			delete(lastActive, id)
 		}
 	}
 	lastActiveMu.Unlock()
@@ -230,25 +764,186 @@ func updateAllPanes() {
 			delete(windowPromptSig, w)
 		}
 	}
+	for w := range windowPublished {
+		if !seenWindows[w] {
+			delete(windowPublished, w)
+		}
+	}
 	for w := range windowDoneSig {
 		if !seenWindows[w] {
 			delete(windowDoneSig, w)
 		}
 	}
-	for w := range windowActiveSig {
+	for id := range paneActiveSig {
+		if !seenTargets[id] {
+			delete(paneActiveSig, id)
+		}
+	}
+	for id := range paneActiveAt {
+		if !seenTargets[id] {
+			delete(paneActiveAt, id)
+		}
+	}
+	for w := range windowLastStatus {
+		if !seenWindows[w] {
+			delete(windowLastStatus, w)
+		}
+	}
+	for w := range windowAgent {
 		if !seenWindows[w] {
-			delete(windowActiveSig, w)
+			delete(windowAgent, w)
 		}
 	}
-	for w := range windowActiveAt {
+	for w := range windowExit {
 		if !seenWindows[w] {
-			delete(windowActiveAt, w)
+			delete(windowExit, w)
+		}
+	}
+	for id := range paneFingerprint {
+		if !seenTargets[id] {
+			delete(paneFingerprint, id)
+		}
+	}
+	for id := range paneTranscript {
+		if !seenPanes[id] {
+			delete(paneTranscript, id)
+		}
+	}
+	for id := range paneRollout {
+		if !seenPanes[id] {
+			delete(paneRollout, id)
+		}
+	}
+	pruneRolloutMetaCache()
+	for w := range windowSnooze {
+		if !seenWindows[w] {
+			delete(windowSnooze, w)
+		}
+	}
+	for id := range paneTask {
+		if !seenTargets[id] {
+			delete(paneTask, id)
+		}
+	}
+	for id := range paneTaskScreen {
+		if !seenTargets[id] {
+			delete(paneTaskScreen, id)
+		}
+	}
+	for id := range paneResult {
+		if !seenTargets[id] {
+			delete(paneResult, id)
+		}
+	}
+	for w := range windowClass {
+		if !seenWindows[w] {
+			delete(windowClass, w)
+		}
+	}
+	for w := range windowRemind {
+		if !seenWindows[w] {
+			delete(windowRemind, w)
+		}
+	}
+	for id := range paneOption {
+		if !seenPanes[id] || !cfg.MultiPane {
+			setPaneOption(id, "") // agent left, or multi_pane turned off
+		}
+	}
+	for id := range paneSeen {
+		if !seenPanes[id] {
+			delete(paneSeen, id)
+			delete(paneUnread, id)
+			delete(paneWasWorking, id)
+			delete(paneEventSig, id)
+		}
+	}
+	for w := range windowBell {
+		if !seenWindows[w] {
+			delete(windowBell, w)
+		}
+	}
+	for id := range paneTitle {
+		if !seenPanes[id] {
+			delete(paneTitle, id)
+		}
+	}
+	for id := range paneStatusSince {
+		if !seenPanes[id] {
+			delete(paneStatusSince, id)
 		}
 	}
 }
 
+// trackAgentExit records the agent seen in a window and returns the exit
+// record while the window should still show it. A new agent clears it.
+func trackAgentExit(window, rawStatus string, agent agentProc, now time.Time) *exitRecord {
+	if rawStatus != "" {
+		delete(windowExit, window)
+		windowLastStatus[window] = rawStatus
+		windowAgent[window] = agent
+		return nil
+	}
+
+	if last, ok := windowLastStatus[window]; ok {
+		// The exit code is gone by now: the pane shell reaps the agent as
+		// soon as it exits, and neither hooks nor rollouts report it.
+		windowExit[window] = &exitRecord{agent: windowAgent[window], last: last, at: now}
+		delete(windowLastStatus, window)
+		delete(windowAgent, window)
+	}
+
+	exit, ok := windowExit[window]
+	if !ok {
+		return nil
+	}
+	if now.Sub(exit.at) >= cfg.ExitLinger.Duration {
+		delete(windowExit, window)
+		return nil
+	}
+	return exit
+}
+
+// exitStatus renders the last agent status followed by the exit marker:
+// "c 🧠🚪", or "c 📬🚪" when unread.
+func exitStatus(exit *exitRecord, unread bool) string {
+	status := exit.last
+	if unread {
+		status = statusPrefix(status) + "📬"
+	}
+	return status + "🚪"
+}
+
+// unreadStatus marks an idle or interrupted status unread: "c 💤" becomes
+// "c 📬" and "c ✋" becomes "c 📬✋", like an exit keeps its 🚪.
+func unreadStatus(status string) string {
+	switch {
+	case strings.HasSuffix(status, "💤"):
+		return strings.TrimSuffix(status, "💤") + "📬"
+	case strings.HasSuffix(status, "✋") && !strings.Contains(status, "📬"):
+		return strings.TrimSuffix(status, "✋") + "📬✋"
+	}
+	return status
+}
+
+// statusPrefix returns the agent prefix ("c ", "x ") of a status.
+func statusPrefix(status string) string {
+	if i := strings.Index(status, " "); i >= 0 {
+		return status[:i+1]
+	}
+	return ""
+}
+
 func isWorkingStatus(status string) bool {
-	return status != "" && !strings.HasSuffix(status, "💤")
+	return status != "" && !isIdleStatus(status)
+}
+
+// isIdleStatus reports whether the agent is waiting on the user:
+// idle (💤), interrupted (✋) or exited (🚪).
+func isIdleStatus(status string) bool {
+	return strings.HasSuffix(status, "💤") ||
+		strings.HasSuffix(status, "✋") ||
+		strings.Contains(status, "🚪")
 }
 
 func statusPriority(status string) int {
@@ -295,24 +990,44 @@ func shouldMarkUnread(
 	seenBefore bool,
 	promptSig, prevPromptSig, doneSig, prevDoneSig string,
 ) bool {
-	if focused || isWorking || rawStatus == "" {
-		return false
+	mark, _ := unreadDecision(wasWorking, focused, isWorking, rawStatus, seenBefore,
+		promptSig, prevPromptSig, doneSig, prevDoneSig)
+	return mark
+}
+
+// unreadDecision is shouldMarkUnread plus the rule that decided it.
+func unreadDecision(
+	wasWorking, focused, isWorking bool,
+	rawStatus string,
+	seenBefore bool,
+	promptSig, prevPromptSig, doneSig, prevDoneSig string,
+) (bool, string) {
+	switch {
+	case focused:
+		return false, "focused"
+	case isWorking:
+		return false, "working"
+	case rawStatus == "":
+		return false, "no agent"
 	}
 	if wasWorking {
-		return true
+		return true, "working -> idle"
 	}
 	if !seenBefore {
 		// First baseline should stay read for bare prompts, but explicit
 		// prompt text ("› Run /review...") indicates immediate attention.
-		return hasPromptText(promptSig)
+		if hasPromptText(promptSig) {
+			return true, "first sight, prompt has text"
+		}
+		return false, "first sight, baseline"
 	}
 	if doneSig != "" && doneSig != prevDoneSig {
-		return true
+		return true, "new completion signature"
 	}
 	if promptSig != "" && promptSig != prevPromptSig {
-		return true
+		return true, "new prompt signature"
 	}
-	return false
+	return false, "no new signature"
 }
 
 func hasPromptText(promptSig string) bool {
@@ -333,7 +1048,8 @@ func hasPromptText(promptSig string) bool {
 
 // setWindowStatus applies hysteresis: a new status must be seen for
 // stabilityThreshold consecutive cycles before the tmux tab is updated.
-func setWindowStatus(window, status string) {
+// It reports the status it replaced and whether the tab was updated.
+func setWindowStatus(window, status string) (string, bool) {
 	statusStateMu.Lock()
 	defer statusStateMu.Unlock()
 
@@ -347,7 +1063,7 @@ func setWindowStatus(window, status string) {
 	if status == ws.applied {
 		ws.pending = ""
 		ws.count = 0
-		return
+		return "", false
 	}
 
 	// New candidate status
@@ -360,18 +1076,27 @@ func setWindowStatus(window, status string) {
 
 	// Only apply once stable
 	if ws.count < stabilityThreshold {
-		return
+		return "", false
 	}
 
+	old := ws.applied
 	ws.applied = status
 	ws.pending = ""
 	ws.count = 0
 
+	if !dryRun {
+		exec.Command("tmux", tmuxStatusArgs(window, status)...).Run()
+	}
+	return old, true
+}
+
+// tmuxStatusArgs is the tmux command that shows status on a window; an
+// empty status hands the name back to automatic-rename.
+func tmuxStatusArgs(window, status string) []string {
 	if status != "" {
-		exec.Command("tmux", "rename-window", "-t", window, status).Run()
-	} else {
-		exec.Command("tmux", "set-option", "-t", window, "automatic-rename", "on").Run()
+		return []string{"rename-window", "-t", window, status}
 	}
+	return []string{"set-option", "-t", window, "automatic-rename", "on"}
 }
 
 func buildChildMap() map[int][]int {
@@ -404,6 +1129,93 @@ func readPPID(pid int) int {
 	return parsePPIDFromStat(string(data))
 }
 
+func readCwd(pid int) string {
+	cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	if err != nil {
+		return ""
+	}
+	return cwd
+}
+
+// clockTicks is USER_HZ, which is 100 on every mainstream Linux build.
+const clockTicks = 100
+
+// procStartTime returns when a process started, or the zero time.
+func procStartTime(pid int) time.Time {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return time.Time{}
+	}
+	ticks := parseStartTicksFromStat(string(stat))
+	boot := readBootTime()
+	if ticks < 0 || boot.IsZero() {
+		return time.Time{}
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+}
+
+func parseStartTicksFromStat(stat string) int64 {
+	i := strings.LastIndex(stat, ")")
+	if i < 0 || i+2 >= len(stat) {
+		return -1
+	}
+	fields := strings.Fields(stat[i+2:])
+	// fields[0] is state (field 3); starttime is field 22.
+	if len(fields) < 20 {
+		return -1
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return ticks
+}
+
+func readBootTime() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return time.Time{}
+			}
+			return time.Unix(secs, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// readExitCode returns the exit code of a process that has exited but not
+// yet been reaped by its parent, or -1. Tasks are caught this way only
+// when the agent is slow to reap them; the pane summary is the fallback.
+func readExitCode(pid int) int {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return -1
+	}
+	return parseExitCodeFromStat(string(data))
+}
+
+func parseExitCodeFromStat(stat string) int {
+	i := strings.LastIndex(stat, ")")
+	if i < 0 || i+2 >= len(stat) {
+		return -1
+	}
+	fields := strings.Fields(stat[i+2:])
+	// fields[0] is state (field 3); exit_code is field 52.
+	if len(fields) < 50 || fields[0] != "Z" {
+		return -1
+	}
+	status, err := strconv.Atoi(fields[49])
+	if err != nil {
+		return -1
+	}
+	return (status >> 8) & 0xff
+}
+
 func parsePPIDFromStat(stat string) int {
 	i := strings.LastIndex(stat, ")")
 	if i < 0 || i+2 >= len(stat) {
@@ -417,10 +1229,30 @@ func parsePPIDFromStat(stat string) int {
 	return ppid
 }
 
-func getStatus(window string, panePID int, childMap map[int][]int, paneCache map[string]*paneCapture) string {
+func getStatus(pane string, panePID int, childMap map[int][]int, paneCache map[string]*paneCapture) string {
 	agentPID, agentName := findAgent(panePID, childMap)
+	status, _ := agentStatus(pane, agentPID, agentName, childMap, paneCache)
+	return status
+}
+
+// taskRoot returns the agent's direct child that pid descends from: the
+// shell of one tool call, which lives as long as the whole command.
+func taskRoot(pid, agentPID int) int {
+	for range 64 {
+		ppid := readPPID(pid)
+		if ppid == agentPID || ppid <= 1 {
+			return pid
+		}
+		pid = ppid
+	}
+	return pid
+}
+
+// agentStatus classifies an agent and also returns the worker task it is
+// running, if any, so the caller can follow the task across cycles.
+func agentStatus(pane string, agentPID int, agentName string, childMap map[int][]int, paneCache map[string]*paneCapture) (string, childTask) {
 	if agentPID == 0 {
-		return ""
+		return "", childTask{}
 	}
 
 	prefix := "c "
@@ -431,10 +1263,12 @@ func getStatus(window string, panePID int, childMap map[int][]int, paneCache map
 	descendants := collectDescendants(agentPID, childMap)
 
 	var childSignals []string
+	var childPIDs []int
 	for _, d := range descendants {
 		comm := strings.ToLower(readComm(d))
 		cmdline := strings.ToLower(readCmdline(d))
 		if isAgentLikeProcess(comm, cmdline) {
+			tracef("child pid %d %s: skipped, agent-like", d, comm)
 			continue
 		}
 		signal := cmdline
@@ -444,29 +1278,59 @@ func getStatus(window string, panePID int, childMap map[int][]int, paneCache map
 		if signal == "" {
 			continue
 		}
+		tracef("child pid %d: %s", d, signal)
 		childSignals = append(childSignals, signal)
+		childPIDs = append(childPIDs, d)
 	}
 
+	if len(descendants) == 0 {
+		tracef("children: none")
+	}
 	if len(childSignals) > 0 {
-		childStatus := classifyChildren(childSignals)
+		childStatus, keyword := matchChildren(childSignals)
+		if keyword != "" {
+			tracef("children: %s (matched %q)", childStatus, keyword)
+		} else {
+			tracef("children: %s (no keyword matched)", childStatus)
+		}
+		task := childTask{icon: childStatus, pids: childPIDs}
+		if keyword != "" {
+			for i, signal := range childSignals {
+				if strings.Contains(signal, keyword) {
+					task.root = taskRoot(childPIDs[i], agentPID)
+					task.started = procStartTime(task.root)
+					break
+				}
+			}
+		}
 		if childStatus == "⚙️" {
 			return unknownChildStatus(
 				prefix,
-				isPaneActive(window, paneCache),
-				paneNeedsAttention(window, paneCache),
-			)
+				isPaneActive(pane, paneCache),
+				paneNeedsAttention(pane, paneCache),
+			), task
 		}
-		return prefix + childStatus
+		return prefix + childStatus, task
 	}
 
+	// Compaction runs inside the agent itself and can take a while;
+	// report it as its own working state rather than plain thinking.
+	if paneCompacting(pane, paneCache) {
+		tracef("pane: compacting")
+		return prefix + "🗜️", childTask{}
+	}
 	// If no child process is active, prompt means idle/waiting.
-	if paneNeedsAttention(window, paneCache) {
-		return prefix + "💤"
+	if paneNeedsAttention(pane, paneCache) {
+		tracef("pane: prompt visible, no worker child")
+		if paneInterrupted(pane, paneCache) {
+			return prefix + "✋", childTask{}
+		}
+		return prefix + "💤", childTask{}
 	}
-	if isPaneActive(window, paneCache) {
-		return prefix + "🧠"
+	if isPaneActive(pane, paneCache) {
+		return prefix + "🧠", childTask{}
 	}
-	return prefix + "💤"
+	return prefix + "💤", childTask{}
 }
 
 func unknownChildStatus(prefix string, paneActive, needsAttention bool) string {
@@ -481,88 +1345,105 @@ func unknownChildStatus(prefix string, paneActive, needsAttention bool) string {
 	return prefix + "⚙️"
 }
 
-func paneNeedsAttention(window string, paneCache map[string]*paneCapture) bool {
-	content, ok := getPaneContent(window, paneCache)
+func paneNeedsAttention(pane string, paneCache map[string]*paneCapture) bool {
+	content, ok := getPaneContent(pane, paneCache)
 	if !ok {
 		return false
 	}
+	// A prompt is always drawn in Claude's input box, so don't call the
+	// pane idle while the screen above it keeps changing.
+	if paneMotion(pane, paneCache) > 0 {
+		return false
+	}
 	return classifyPaneNeedsAttention(content)
 }
 
-func paneSignals(window string, paneCache map[string]*paneCapture) (promptSig, doneSig string) {
-	content, ok := getPaneContent(window, paneCache)
+func paneSignals(pane string, paneCache map[string]*paneCapture) (promptSig, doneSig string) {
+	content, ok := getPaneContent(pane, paneCache)
 	if !ok {
 		return "", ""
 	}
 	return classifyPaneAttentionSignature(content), classifyPaneCompletionSignature(content)
 }
 
-// isPaneActive captures the pane content and checks for activity indicators.
+// isPaneActive captures the pane content and checks for activity indicators,
+// weighed against screen motion (see motion.go).
 // Uses a grace period to prevent flashing during spinner redraws.
-func isPaneActive(window string, paneCache map[string]*paneCapture) bool {
+func isPaneActive(pane string, paneCache map[string]*paneCapture) bool {
 	now := time.Now()
 	active := false
 
-	if content, ok := getPaneContent(window, paneCache); ok {
-		active = classifyPaneContent(content)
-		if active {
-			active = !isStaleActiveMarker(window, content, now)
+	if content, ok := getPaneContent(pane, paneCache); ok {
+		marker := classifyPaneContent(content)
+		if marker {
+			marker = !isStaleActiveMarker(pane, content, now)
+			if !marker {
+				tracef("activity: active marker is stale")
+			}
 		} else {
-			clearActiveMarker(window)
+			clearActiveMarker(pane)
 		}
+		motion := paneMotion(pane, paneCache)
+		active = isActiveScore(marker, motion)
+		tracef("activity: marker %v, motion %d -> active %v", marker, motion, active)
 	} else {
-		clearActiveMarker(window)
+		clearActiveMarker(pane)
 	}
 
 	lastActiveMu.Lock()
 	defer lastActiveMu.Unlock()
 
 	if active {
-		lastActive[window] = now
+		lastActive[pane] = now
 		return true
 	}
 
 	// Not detected as active right now — check grace period
-	if last, ok := lastActive[window]; ok {
+	if last, ok := lastActive[pane]; ok {
 		if now.Sub(last) < activeGrace {
+			tracef("activity: within %s grace of last activity", activeGrace)
 			return true
 		}
-		delete(lastActive, window)
+		delete(lastActive, pane)
 	}
 	return false
 }
 
 const staleActiveThreshold = 12 * time.Second
 
-func isStaleActiveMarker(window, content string, now time.Time) bool {
+func isStaleActiveMarker(pane, content string, now time.Time) bool {
 	activeSig := classifyPaneActiveSignature(content)
 	if activeSig == "" {
 		return false
 	}
+	// Compaction legitimately holds the same marker for a long time.
+	if isCompactingLine(activeSig) {
+		return false
+	}
 	promptSig := detectPromptSignature(content)
 	if promptSig == "" {
-		windowActiveSig[window] = activeSig
-		windowActiveAt[window] = now
+		paneActiveSig[pane] = activeSig
+		paneActiveAt[pane] = now
 		return false
 	}
 
-	prevSig, ok := windowActiveSig[window]
+	prevSig, ok := paneActiveSig[pane]
 	if !ok || prevSig != activeSig {
-		windowActiveSig[window] = activeSig
-		windowActiveAt[window] = now
+		paneActiveSig[pane] = activeSig
+		paneActiveAt[pane] = now
 		return false
 	}
-	startedAt, ok := windowActiveAt[window]
+	startedAt, ok := paneActiveAt[pane]
 	if !ok {
-		windowActiveAt[window] = now
+		paneActiveAt[pane] = now
 		return false
 	}
 	return now.Sub(startedAt) >= staleActiveThreshold
 }
 
-func clearActiveMarker(window string) {
-	delete(windowActiveSig, window)
-	delete(windowActiveAt, window)
+func clearActiveMarker(pane string) {
+	delete(paneActiveSig, pane)
+	delete(paneActiveAt, pane)
 }
 
 // classifyPaneContent returns true if the pane content indicates active work.
@@ -670,6 +1551,267 @@ func classifyPaneCompletionSignature(content string) string {
 	return ""
 }
 
+func paneInterrupted(pane string, paneCache map[string]*paneCapture) bool {
+	content, ok := getPaneContent(pane, paneCache)
+	if !ok {
+		return false
+	}
+	return classifyPaneInterrupted(content)
+}
+
+// classifyPaneInterrupted returns true when the last turn was cancelled
+// with Esc: Claude prints "⎿  Interrupted by user", Codex prints
+// "■ Conversation interrupted - tell the model what to do differently".
+func classifyPaneInterrupted(content string) bool {
+	lines := strings.Split(content, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < 12; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		checked++
+		if isCompletionLine(line) {
+			return false
+		}
+		if strings.Contains(line, "Interrupted by user") ||
+			strings.Contains(line, "Conversation interrupted") {
+			return true
+		}
+	}
+	return false
+}
+
+func paneCompacting(pane string, paneCache map[string]*paneCapture) bool {
+	content, ok := getPaneContent(pane, paneCache)
+	if !ok {
+		return false
+	}
+	return classifyPaneFooter(content).compacting
+}
+
+func paneFooterInfo(pane string, paneCache map[string]*paneCapture) paneFooter {
+	content, ok := getPaneContent(pane, paneCache)
+	if !ok {
+		return noFooter
+	}
+	return classifyPaneFooter(content)
+}
+
+// contextWarnPercent is the remaining-context level at or below which
+// the tab gets a warning badge.
+const contextWarnPercent = 10
+
+// paneFooter holds what the agent's footer says about the session.
+type paneFooter struct {
+	contextLeft int    // percent of context window left, -1 when unknown
+	compacting  bool   // agent is compacting the conversation
+	mode        string // one of the mode* constants, "" for default
+	modeShown   bool   // Claude's mode/shortcuts line is on screen
+	result      string // outcome badge of the last test/build task, e.g. "🧪❌"
+	model       string // model name reported by `statusline`
+	costUSD     float64
+}
+
+// Agent modes, from least to most autonomous.
+const (
+	modePlan        = "plan"
+	modeAcceptEdits = "accept-edits"
+	modeFullAuto    = "full-auto"
+	modeBypass      = "bypass"
+)
+
+var modeBadges = map[string]string{
+	modePlan:        "📋",
+	modeAcceptEdits: "✏️",
+	modeFullAuto:    "🚀",
+	modeBypass:      "⚡",
+}
+
+var noFooter = paneFooter{contextLeft: -1, costUSD: -1}
+
+// classifyPaneFooter extracts context usage and compaction state from the
+// bottom of the pane. Claude prints "Context left until auto-compact: 12%"
+// and "Compacting conversation…"; Codex shows "87% left" (or "87% context
+// left") in its footer.
+func classifyPaneFooter(content string) paneFooter {
+	footer := noFooter
+	lines := strings.Split(content, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < 12; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		checked++
+		if isCompletionLine(line) {
+			break
+		}
+		if isCompactingLine(line) {
+			footer.compacting = true
+		}
+		if footer.contextLeft < 0 {
+			footer.contextLeft = parseContextLeft(line)
+		}
+		if footer.mode == "" {
+			footer.mode = parseFooterMode(line)
+		}
+		if footer.mode != "" || strings.Contains(line, "? for shortcuts") {
+			footer.modeShown = true
+		}
+	}
+	return footer
+}
+
+// parseFooterMode recognises Claude's shift+tab mode line, e.g.
+// "⏸ plan mode on (shift+tab to cycle)" or "⏵⏵ accept edits on".
+func parseFooterMode(line string) string {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.Contains(lower, "plan mode on"):
+		return modePlan
+	case strings.Contains(lower, "accept edits on"):
+		return modeAcceptEdits
+	case strings.Contains(lower, "bypass permissions on"):
+		return modeBypass
+	}
+	return ""
+}
+
+// classifyCmdlineMode derives the mode from launch flags. Codex does not
+// keep its approval/sandbox policy in the footer, and Claude started with
+// --permission-mode or --dangerously-skip-permissions may scroll its mode
+// line away. Flags may be given as "--flag value" or "--flag=value"; the
+// most autonomous one wins.
+func classifyCmdlineMode(argv []string) string {
+	rank := map[string]int{"": 0, modePlan: 1, modeAcceptEdits: 2, modeFullAuto: 3, modeBypass: 4}
+	best := ""
+	for i := 1; i < len(argv); i++ {
+		flag, value, inline := strings.Cut(argv[i], "=")
+		if !strings.HasPrefix(flag, "-") {
+			continue // the prompt or another positional argument
+		}
+		if !inline && i+1 < len(argv) {
+			value = argv[i+1]
+		}
+		if flag == "-c" || flag == "--config" {
+			// Codex config overrides: -c approval_policy="never".
+			flag, value, _ = strings.Cut(value, "=")
+			value = strings.Trim(value, `"'`)
+		}
+		mode := ""
+		switch strings.ToLower(flag + " " + value) {
+		case "--permission-mode plan":
+			mode = modePlan
+		case "--permission-mode acceptedits":
+			mode = modeAcceptEdits
+		case "--permission-mode bypasspermissions",
+			"-s danger-full-access", "--sandbox danger-full-access",
+			"sandbox_mode danger-full-access":
+			mode = modeBypass
+		case "-a never", "--ask-for-approval never", "approval_policy never":
+			mode = modeFullAuto
+		}
+		switch flag {
+		case "--dangerously-bypass-approvals-and-sandbox", "--yolo", "--dangerously-skip-permissions":
+			mode = modeBypass
+		case "--full-auto":
+			mode = modeFullAuto
+		}
+		if rank[mode] > rank[best] {
+			best = mode
+		}
+	}
+	return best
+}
+
+func isCompactingLine(line string) bool {
+	if !strings.Contains(line, "Compacting conversation") {
+		return false
+	}
+	return hasSpinnerMarker(line) || strings.Contains(line, "esc to interrupt")
+}
+
+func parseContextLeft(line string) int {
+	// Footers are fields joined by " · " or wide gaps; the gauge is a
+	// field of its own, which a sentence mentioning "% left" is not.
+	for _, field := range footerFields(line) {
+		if rest, ok := strings.CutPrefix(field, "Context left until auto-compact:"); ok {
+			return leadingPercent(strings.TrimSpace(rest))
+		}
+		for _, suffix := range []string{"% context left", "% left"} {
+			if n, ok := strings.CutSuffix(field, suffix); ok {
+				if p := leadingPercent(n + "%"); p >= 0 && len(n) == len(strconv.Itoa(p)) {
+					return p
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// footerFields splits a status line into its fields.
+func footerFields(line string) []string {
+	var fields []string
+	for _, part := range strings.Split(line, "·") {
+		for _, f := range strings.Split(part, "  ") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// leadingPercent parses "12%" (and anything following) into 12.
+func leadingPercent(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 || end >= len(s) || s[end] != '%' {
+		return -1
+	}
+	n, err := strconv.Atoi(s[:end])
+	if err != nil || n > 100 {
+		return -1
+	}
+	return n
+}
+
+// renderWindowName fills cfg.Format with the status and footer badges.
+func renderWindowName(status string, footer paneFooter) string {
+	mode := ""
+	if badge := modeBadges[footer.mode]; badge != "" {
+		mode = " " + badge
+	}
+	context := ""
+	if footer.contextLeft >= 0 && footer.contextLeft <= contextWarnPercent {
+		context = " ⚠️"
+	}
+	result := ""
+	if footer.result != "" {
+		result = " " + footer.result
+	}
+	model := ""
+	if footer.model != "" {
+		model = " " + footer.model
+	}
+	cost := ""
+	if footer.costUSD >= 0 && footer.model != "" {
+		cost = " " + formatCost(footer.costUSD)
+	}
+	name := strings.NewReplacer(
+		"{status}", status,
+		"{result}", result,
+		"{model}", model,
+		"{cost}", cost,
+		"{mode}", mode,
+		"{context}", context,
+	).Replace(cfg.Format)
+	return strings.TrimSpace(name)
+}
+
 func isCompletionLine(line string) bool {
 	return strings.HasPrefix(line, "─ Worked for ") ||
 		line == "Done." || strings.HasPrefix(line, "Done. ") ||
@@ -720,6 +1862,15 @@ func readCmdline(pid int) string {
 	return strings.ReplaceAll(string(data), "\x00", " ")
 }
 
+// readArgv returns a process's arguments, which may contain spaces.
+func readArgv(pid int) []string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+}
+
 func readComm(pid int) string {
 	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
 	if err != nil {
@@ -728,29 +1879,37 @@ func readComm(pid int) string {
 	return strings.TrimSpace(string(data))
 }
 
-func classifyChildren(names []string) string {
-	joined := strings.ToLower(strings.Join(names, "\n"))
-
-	if containsAny(
-		joined,
+// childClasses are checked in order; the first keyword found wins.
+var childClasses = []struct {
+	icon     string
+	keywords []string
+}{
+	{"🔨", []string{
 		"make", "gcc", "g++", "cc1", "rustc", "javac", "tsc", "webpack", "vite", "esbuild", "rollup",
 		"coordinator/cli.ts build", " next build", "npm run build", "pnpm run build", "yarn build", "go build", "cargo build",
-	) {
-		return "🔨"
-	}
-	if containsAny(joined, "jest", "vitest", "pytest", "mocha", "phpunit", "rspec") {
-		return "🧪"
-	}
-	if containsAny(joined, "npm", "yarn", "pnpm", "pip", "apt", "brew", "pacman") {
-		return "📦"
-	}
-	if containsAny(joined, "git") {
-		return "🔀"
-	}
-	if containsAny(joined, "curl", "wget") {
-		return "🌐"
+	}},
+	{"🧪", []string{"jest", "vitest", "pytest", "mocha", "phpunit", "rspec", "go test", "cargo test"}},
+	{"📦", []string{"npm", "yarn", "pnpm", "pip", "apt", "brew", "pacman"}},
+	{"🔀", []string{"git"}},
+	{"🌐", []string{"curl", "wget"}},
+}
+
+func classifyChildren(names []string) string {
+	icon, _ := matchChildren(names)
+	return icon
+}
+
+// matchChildren is classifyChildren plus the keyword that decided it.
+func matchChildren(names []string) (icon, keyword string) {
+	joined := strings.ToLower(strings.Join(names, "\n"))
+	for _, c := range childClasses {
+		for _, k := range c.keywords {
+			if strings.Contains(joined, k) {
+				return c.icon, k
+			}
+		}
 	}
-	return "⚙️"
+	return "⚙️", ""
 }
 
 func isAgentLikeProcess(comm, cmdline string) bool {
@@ -2,7 +2,9 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -41,6 +43,8 @@ func TestClassifyChildren(t *testing.T) {
 		{[]string{"rustc"}, "🔨"},
 		{[]string{"jest"}, "🧪"},
 		{[]string{"pytest"}, "🧪"},
+		{[]string{"go test ./..."}, "🧪"},
+		{[]string{"cargo test"}, "🧪"},
 		{[]string{"npm"}, "📦"},
 		{[]string{"pip"}, "📦"},
 		{[]string{"git"}, "🔀"},
@@ -177,6 +181,12 @@ func TestReadCmdline_Self(t *testing.T) {
 	}
 }
 
+func TestReadArgv_Self(t *testing.T) {
+	if argv := readArgv(os.Getpid()); len(argv) != len(os.Args) || argv[0] != os.Args[0] {
+		t.Errorf("readArgv(self) = %q, want %q", argv, os.Args)
+	}
+}
+
 func TestReadComm_InvalidPID(t *testing.T) {
 	if readComm(999999999) != "" {
 		t.Error("should be empty for invalid PID")
@@ -239,6 +249,36 @@ func TestListPanes_ParsesOutput(t *testing.T) {
 	}
 }
 
+func TestListPanes_ParsesTabFields(t *testing.T) {
+	orig := listPanesOutput
+	defer func() { listPanesOutput = orig }()
+
+	listPanesOutput = func() ([]byte, error) {
+		return []byte("s:1\t123\t0\t%4\t1\t✳ Fix the login bug\tnode\t/home/me/my project\tc 📬\t@7\t1\n"), nil
+	}
+
+	got := listPanes()
+	want := paneInfo{window: "s:1", pid: 123, paneID: "%4", bell: true,
+		title: "✳ Fix the login bug", command: "node", path: "/home/me/my project", option: "c 📬", winID: "@7", styled: true}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("panes = %+v, want %+v", got, want)
+	}
+}
+
+func TestListPanes_ParsesPaneID(t *testing.T) {
+	orig := listPanesOutput
+	defer func() { listPanesOutput = orig }()
+
+	listPanesOutput = func() ([]byte, error) {
+		return []byte("s:1 123 1 %4\ns:1 124 1 %5\n"), nil
+	}
+
+	got := listPanes()
+	if len(got) != 2 || got[0].paneID != "%4" || got[1].paneID != "%5" {
+		t.Errorf("unexpected panes: %+v", got)
+	}
+}
+
 func TestGetPaneContent_CachesSuccess(t *testing.T) {
 	orig := capturePaneOutput
 	defer func() { capturePaneOutput = orig }()
@@ -450,11 +490,11 @@ func TestIsStaleActiveMarker(t *testing.T) {
 	window := "test:stale-active"
 	content := "◦ Planning broad tests and monitoring (1m 03s • esc to interrupt)\n› Find and fix a bug in @filename\n"
 
-	delete(windowActiveSig, window)
-	delete(windowActiveAt, window)
+	delete(paneActiveSig, window)
+	delete(paneActiveAt, window)
 	defer func() {
-		delete(windowActiveSig, window)
-		delete(windowActiveAt, window)
+		delete(paneActiveSig, window)
+		delete(paneActiveAt, window)
 	}()
 
 	now := time.Now()
@@ -497,6 +537,273 @@ func TestClassifyPaneCompletionSignature(t *testing.T) {
 	}
 }
 
+func TestClassifyPaneFooter(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contextLeft int
+		compacting  bool
+		mode        string
+		modeShown   bool
+	}{
+		{
+			name:        "claude auto-compact warning",
+			content:     "❯ \n──────\n  ⏵⏵ accept edits on · Context left until auto-compact: 8%\n",
+			contextLeft: 8,
+			mode:        modeAcceptEdits,
+			modeShown:   true,
+		},
+		{
+			name:        "claude default mode",
+			content:     "❯ \n──────\n  ? for shortcuts\n",
+			contextLeft: -1,
+			modeShown:   true,
+		},
+		{
+			name:        "codex footer",
+			content:     "› Explain this codebase\n\n  gpt-5.3-codex · 87% left · ~/src\n",
+			contextLeft: 87,
+		},
+		{
+			name:        "codex context left footer",
+			content:     "› \n  gpt-5.3-codex high · 4% context left\n",
+			contextLeft: 4,
+		},
+		{
+			name:        "percent left in a reply",
+			content:     "Only 30% left of the quota, so I stopped.\n› \n",
+			contextLeft: -1,
+		},
+		{
+			name:        "reply above the codex footer",
+			content:     "Done: 3% left to migrate.\n› \n  gpt-5.3-codex · 87% left · ~/src\n",
+			contextLeft: 87,
+		},
+		{
+			name:        "codex footer with key hints",
+			content:     "› \n  ⏎ send   ⌃J newline   ⌃C quit   42% context left\n",
+			contextLeft: 42,
+		},
+		{
+			name:        "claude compacting",
+			content:     "✻ Compacting conversation… (34s · esc to interrupt)\n❯ \n",
+			contextLeft: -1,
+			compacting:  true,
+		},
+		{
+			name:        "compaction mentioned in prose",
+			content:     "Compacting conversation history is a Claude feature.\n❯ \n",
+			contextLeft: -1,
+		},
+		{
+			name:        "no footer",
+			content:     "$ ls\nfile1\n$ \n",
+			contextLeft: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyPaneFooter(tt.content)
+			if got.contextLeft != tt.contextLeft || got.compacting != tt.compacting || got.mode != tt.mode || got.modeShown != tt.modeShown {
+				t.Errorf("classifyPaneFooter() = %+v, want contextLeft=%d compacting=%v mode=%q modeShown=%v",
+					got, tt.contextLeft, tt.compacting, tt.mode, tt.modeShown)
+			}
+		})
+	}
+}
+
+func TestRenderWindowName(t *testing.T) {
+	if got := renderWindowName("c 🧠", paneFooter{contextLeft: 5}); got != "c 🧠 ⚠️" {
+		t.Errorf("low context: got %q", got)
+	}
+	if got := renderWindowName("c 🧠", paneFooter{contextLeft: 50}); got != "c 🧠" {
+		t.Errorf("plenty of context: got %q", got)
+	}
+	if got := renderWindowName("x 💤", noFooter); got != "x 💤" {
+		t.Errorf("unknown context: got %q", got)
+	}
+	if got := renderWindowName("c 🧠", paneFooter{contextLeft: 5, mode: modePlan}); got != "c 🧠 📋 ⚠️" {
+		t.Errorf("plan mode with low context: got %q", got)
+	}
+}
+
+func TestRenderWindowName_CustomFormat(t *testing.T) {
+	orig := cfg
+	defer func() { cfg = orig }()
+	cfg.Format = "{mode} {status}"
+
+	if got := renderWindowName("x 🧠", paneFooter{contextLeft: -1, mode: modeBypass}); got != "⚡ x 🧠" {
+		t.Errorf("got %q", got)
+	}
+	if got := renderWindowName("x 🧠", noFooter); got != "x 🧠" {
+		t.Errorf("empty badge should not leave padding, got %q", got)
+	}
+}
+
+func TestParseFooterMode(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"⏸ plan mode on (shift+tab to cycle)", modePlan},
+		{"⏵⏵ accept edits on (shift+tab to cycle)", modeAcceptEdits},
+		{"⏵⏵ bypass permissions on", modeBypass},
+		{"🟢 19%", ""},
+	}
+	for _, tt := range tests {
+		if got := parseFooterMode(tt.line); got != tt.want {
+			t.Errorf("parseFooterMode(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyCmdlineMode(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		want    string
+	}{
+		{"/usr/bin/codex --dangerously-bypass-approvals-and-sandbox", modeBypass},
+		{"/usr/bin/codex --full-auto", modeFullAuto},
+		{"/usr/bin/codex -a never -s workspace-write", modeFullAuto},
+		{"/usr/bin/codex --sandbox danger-full-access", modeBypass},
+		{"node /usr/bin/claude --dangerously-skip-permissions", modeBypass},
+		{"node /usr/bin/claude --permission-mode plan", modePlan},
+		{"node /usr/bin/claude --permission-mode acceptEdits", modeAcceptEdits},
+		{"node /usr/bin/claude --permission-mode=plan", modePlan},
+		{"node /usr/bin/claude --permission-mode=acceptEdits", modeAcceptEdits},
+		{"node /usr/bin/claude --permission-mode=bypassPermissions", modeBypass},
+		{"/usr/bin/codex --ask-for-approval=never", modeFullAuto},
+		{"/usr/bin/codex --ask-for-approval never", modeFullAuto},
+		{`/usr/bin/codex -c approval_policy="never"`, modeFullAuto},
+		{"/usr/bin/codex --config sandbox_mode=danger-full-access", modeBypass},
+		{"/usr/bin/codex -a on-request --full-auto --sandbox workspace-write", modeFullAuto},
+		{"/usr/bin/codex", ""},
+	}
+	for _, tt := range tests {
+		if got := classifyCmdlineMode(strings.Fields(tt.cmdline)); got != tt.want {
+			t.Errorf("classifyCmdlineMode(%q) = %q, want %q", tt.cmdline, got, tt.want)
+		}
+	}
+
+	prompt := []string{"/usr/bin/codex", "why is -a never or danger-full-access risky?"}
+	if got := classifyCmdlineMode(prompt); got != "" {
+		t.Errorf("flags inside the prompt: got %q", got)
+	}
+}
+
+func TestIsStaleActiveMarker_Compacting(t *testing.T) {
+	window := "test:stale-compact"
+	content := "✻ Compacting conversation… (esc to interrupt)\n❯ \n"
+
+	delete(paneActiveSig, window)
+	delete(paneActiveAt, window)
+	defer func() {
+		delete(paneActiveSig, window)
+		delete(paneActiveAt, window)
+	}()
+
+	now := time.Now()
+	isStaleActiveMarker(window, content, now)
+	if isStaleActiveMarker(window, content, now.Add(staleActiveThreshold+time.Second)) {
+		t.Error("compaction marker should never be treated as stale")
+	}
+}
+
+func TestClassifyPaneInterrupted(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"claude interrupted", "> fix the tests\n  ⎿  Interrupted by user\n\n❯ \n", true},
+		{"codex interrupted", "■ Conversation interrupted - tell the model what to do differently\n\n› \n", true},
+		{"completed after interrupt", "  ⎿  Interrupted by user\n❯ try again\nDone.\n\n❯ \n", false},
+		{"plain prompt", "All set.\n\n❯ \n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyPaneInterrupted(tt.content); got != tt.want {
+				t.Errorf("classifyPaneInterrupted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExitCodeFromStat(t *testing.T) {
+	tail := strings.Repeat(" 0", 47) // fields 5..51
+	tests := []struct {
+		name string
+		stat string
+		want int
+	}{
+		{"zombie exit 1", "42 (claude) Z 10" + tail + " 256\n", 1},
+		{"zombie exit 0", "42 (claude) Z 10" + tail + " 0\n", 0},
+		{"running", "42 (claude) S 10" + tail + " 0\n", -1},
+		{"old kernel", "42 (claude) Z 10 0 0", -1},
+		{"empty", "", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseExitCodeFromStat(tt.stat); got != tt.want {
+				t.Errorf("parseExitCodeFromStat() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackAgentExit(t *testing.T) {
+	window := "test:exit"
+	defer func() {
+		delete(windowLastStatus, window)
+		delete(windowAgent, window)
+		delete(windowExit, window)
+	}()
+
+	now := time.Now()
+	if exit := trackAgentExit(window, "c 🧠", agentProc{pid: 999999999}, now); exit != nil {
+		t.Fatal("running agent should not report exit")
+	}
+
+	exit := trackAgentExit(window, "", agentProc{}, now.Add(2*time.Second))
+	if exit == nil {
+		t.Fatal("expected exit record after agent disappeared")
+	}
+	if exit.last != "c 🧠" {
+		t.Errorf("unexpected exit record: %+v", exit)
+	}
+	if got := exitStatus(exit, false); got != "c 🧠🚪" {
+		t.Errorf("exitStatus() = %q", got)
+	}
+	if got := exitStatus(exit, true); got != "c 📬🚪" {
+		t.Errorf("unread exitStatus() = %q", got)
+	}
+
+	if trackAgentExit(window, "", agentProc{}, now.Add(cfg.ExitLinger.Duration)) == nil {
+		t.Error("exit should still be shown within the linger period")
+	}
+	if trackAgentExit(window, "", agentProc{}, now.Add(cfg.ExitLinger.Duration+3*time.Second)) != nil {
+		t.Error("exit should expire after the linger period")
+	}
+	if trackAgentExit(window, "", agentProc{}, now.Add(time.Hour)) != nil {
+		t.Error("window without an agent should stay clear")
+	}
+}
+
+func TestUnreadStatus(t *testing.T) {
+	tests := map[string]string{
+		"c 💤":  "c 📬",
+		"x ✋":  "x 📬✋",
+		"x 📬✋": "x 📬✋",
+		"c 🧠":  "c 🧠",
+		"c 🧠🚪": "c 🧠🚪",
+	}
+	for status, want := range tests {
+		if got := unreadStatus(status); got != want {
+			t.Errorf("unreadStatus(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
 // --- Debounce / grace period tests ---
 
 func TestIsPaneActive_GracePeriod(t *testing.T) {
@@ -570,6 +877,9 @@ func TestIsWorkingStatus(t *testing.T) {
 		{"💤", false},
 		{"c 💤", false},
 		{"x 💤", false},
+		{"c ✋", false},
+		{"c 🧠🚪", false},
+		{"x 📬✋", false},
 		{"", false},
 	}
 	for _, tt := range tests {
@@ -768,3 +1078,81 @@ func BenchmarkClassifyPaneContent(b *testing.B) {
 		classifyPaneContent(content)
 	}
 }
+
+// startFakeAgent runs a shell holding a "claude" child, standing in for a
+// pane's shell and the agent inside it. The child blocks until the test
+// ends.
+func startFakeAgent(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", "sh -c 'read x' claude; true")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if pid, _ := findAgent(cmd.Process.Pid, buildChildMap()); pid != 0 {
+			return cmd.Process.Pid
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("fake agent did not start")
+	return 0
+}
+
+func TestUpdateAllPanes_CapturesEachPane(t *testing.T) {
+	origList, origCapture, origMute, origRun := listPanesOutput, capturePaneOutput, listMuteOutput, runTmux
+	origCfg, origDry, origProjects := cfg, dryRun, claudeProjectsDir
+	defer func() {
+		listPanesOutput, capturePaneOutput, listMuteOutput, runTmux = origList, origCapture, origMute, origRun
+		cfg, dryRun, claudeProjectsDir = origCfg, origDry, origProjects
+	}()
+	cfg = defaultConfig()
+	cfg.Notify = false
+	dryRun = true
+	t.Setenv("XDG_RUNTIME_DIR", privateTempDir(t))
+	projects := t.TempDir()
+	claudeProjectsDir = func() string { return projects }
+	listMuteOutput = func() ([]byte, error) { return nil, nil }
+	runTmux = func(args ...string) error { return nil }
+
+	thinking, waiting := startFakeAgent(t), startFakeAgent(t)
+	listPanesOutput = func() ([]byte, error) {
+		return []byte(fmt.Sprintf("u:1\t%d\t0\t%%901\nu:1\t%d\t0\t%%902\n", thinking, waiting)), nil
+	}
+	screens := map[string]string{
+		"%901": "✻ Pondering… (12s · esc to interrupt)\n",
+		"%902": "⏺ All tests pass.\n\n❯ \n  ? for shortcuts\n",
+	}
+	var captured []string
+	capturePaneOutput = func(target string) ([]byte, error) {
+		captured = append(captured, target)
+		screen, ok := screens[target]
+		if !ok {
+			return nil, errors.New("no such pane")
+		}
+		return []byte(screen), nil
+	}
+
+	updateAllPanes()
+
+	got := make(map[string]string)
+	for _, r := range paneReports {
+		got[r.PaneID] = r.RawStatus
+	}
+	if got["%901"] != "c 🧠" || got["%902"] != "c 💤" {
+		t.Errorf("statuses = %v, want %%901 c 🧠 and %%902 c 💤", got)
+	}
+	for _, target := range captured {
+		if _, ok := screens[target]; !ok {
+			t.Errorf("captured %q, want pane ids only", target)
+		}
+	}
+}
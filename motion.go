@@ -0,0 +1,95 @@
+package main
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// Screen-diff activity detection. Marker matching ("ing…", "esc to
+// interrupt") breaks whenever an agent rewords its UI, so we also
+// fingerprint the part of the pane above the prompt every cycle:
+// sustained change reads as activity, sustained stillness as idle.
+// The two signals are combined in isActiveScore so that losing either
+// one degrades detection instead of breaking it.
+var paneFingerprint = make(map[string]*fingerprint)
+
+type fingerprint struct {
+	hash    uint64
+	changed int // consecutive cycles the region changed
+	still   int // consecutive cycles the region stayed the same
+}
+
+const (
+	motionCycles = 2 // changed cycles in a row that count as activity
+	stillCycles  = 3 // unchanged cycles in a row that count as idle
+
+	markerWeight    = 0.6
+	motionWeight    = 0.6
+	activeThreshold = 0.5
+)
+
+// observeMotion records a capture and returns +1 for sustained change,
+// -1 for sustained stillness and 0 while undecided.
+func observeMotion(pane, content string) int {
+	h := fnv.New64a()
+	h.Write([]byte(contentRegion(content)))
+	sum := h.Sum64()
+
+	fp, ok := paneFingerprint[pane]
+	if !ok {
+		paneFingerprint[pane] = &fingerprint{hash: sum}
+		return 0
+	}
+	if sum != fp.hash {
+		fp.hash = sum
+		fp.changed++
+		fp.still = 0
+	} else {
+		fp.still++
+		fp.changed = 0
+	}
+	switch {
+	case fp.changed >= motionCycles:
+		return 1
+	case fp.still >= stillCycles:
+		return -1
+	}
+	return 0
+}
+
+// contentRegion drops the prompt line and everything below it (input
+// box, footer, context meter) so typing or footer updates are not
+// mistaken for agent output.
+func contentRegion(content string) string {
+	lines := strings.Split(content, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < 12; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		checked++
+		if strings.HasPrefix(line, "› ") || line == "›" ||
+			strings.HasPrefix(line, "❯ ") || line == "❯" {
+			return strings.Join(lines[:i], "\n")
+		}
+	}
+	return content
+}
+
+// isActiveScore weighs the marker heuristic against screen motion.
+func isActiveScore(marker bool, motion int) bool {
+	score := motionWeight * float64(motion)
+	if marker {
+		score += markerWeight
+	}
+	return score > activeThreshold
+}
+
+// paneMotion returns the motion observed for this cycle's capture.
+func paneMotion(pane string, paneCache map[string]*paneCapture) int {
+	if _, ok := getPaneContent(pane, paneCache); !ok {
+		return 0
+	}
+	return paneCache[pane].motion
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestObserveMotion(t *testing.T) {
+	window := "test:motion"
+	defer delete(paneFingerprint, window)
+
+	frame := func(n int) string {
+		return fmt.Sprintf("● Writing tests\nline %d\n\n❯ \n", n)
+	}
+
+	if got := observeMotion(window, frame(0)); got != 0 {
+		t.Errorf("first capture should be undecided, got %d", got)
+	}
+	if got := observeMotion(window, frame(1)); got != 0 {
+		t.Errorf("single change should be undecided, got %d", got)
+	}
+	if got := observeMotion(window, frame(2)); got != 1 {
+		t.Errorf("sustained change should be motion, got %d", got)
+	}
+	for i := 1; i < stillCycles; i++ {
+		if got := observeMotion(window, frame(2)); got != 0 {
+			t.Errorf("still cycle %d should be undecided, got %d", i, got)
+		}
+	}
+	if got := observeMotion(window, frame(2)); got != -1 {
+		t.Errorf("sustained stillness should be idle, got %d", got)
+	}
+}
+
+func TestObserveMotion_IgnoresPromptAndFooter(t *testing.T) {
+	window := "test:motion-typing"
+	defer delete(paneFingerprint, window)
+
+	observeMotion(window, "Done.\n\n❯ h\n──────\n  🟢 19%\n")
+	observeMotion(window, "Done.\n\n❯ he\n──────\n  🟢 20%\n")
+	if got := observeMotion(window, "Done.\n\n❯ hel\n──────\n  🟢 21%\n"); got != 0 {
+		t.Errorf("typing at the prompt should not count as motion, got %d", got)
+	}
+}
+
+func TestContentRegion(t *testing.T) {
+	got := contentRegion("output\n\n› Explain this codebase\n\n  gpt-5.3-codex · 87% left\n")
+	if got != "output\n" {
+		t.Errorf("contentRegion() = %q", got)
+	}
+	if got := contentRegion("$ ls\nfile1\n"); got != "$ ls\nfile1\n" {
+		t.Errorf("content without prompt should be kept, got %q", got)
+	}
+}
+
+func TestIsActiveScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		marker bool
+		motion int
+		want   bool
+	}{
+		{"marker alone", true, 0, true},
+		{"marker and motion", true, 1, true},
+		{"frozen marker", true, -1, false},
+		{"motion without known marker", false, 1, true},
+		{"nothing", false, 0, false},
+		{"still", false, -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isActiveScore(tt.marker, tt.motion); got != tt.want {
+				t.Errorf("isActiveScore(%v, %d) = %v, want %v", tt.marker, tt.motion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPaneActive_MotionWithoutMarker(t *testing.T) {
+	window := "test:motion-active"
+	defer func() {
+		delete(paneFingerprint, window)
+		lastActiveMu.Lock()
+		delete(lastActive, window)
+		lastActiveMu.Unlock()
+	}()
+
+	// An agent that renamed its spinner: no known marker, but output keeps changing.
+	for i := 0; i < motionCycles; i++ {
+		observeMotion(window, fmt.Sprintf("◇ Mulling (%ds)\n❯ \n", i))
+	}
+	cache := map[string]*paneCapture{}
+	content := fmt.Sprintf("◇ Mulling (%ds)\n❯ \n", motionCycles)
+	cache[window] = &paneCapture{content: content, ok: true, motion: observeMotion(window, content)}
+
+	if !isPaneActive(window, cache) {
+		t.Error("sustained screen change should be active without a known marker")
+	}
+	if paneNeedsAttention(window, cache) {
+		t.Error("changing screen should not need attention")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// Multi-pane mode: with cfg.MultiPane a window running several agents
+// lists each one ("c📬 x🧠") instead of only the most significant, each
+// pane tracks its own unread state, and every agent pane gets an
+// @ai_pane_status option for pane-border-format. Options the daemon didn't
+// write this run, left by a previous one or a reload that turned the mode
+// off, are adopted so that they get cleared like its own.
+
+const paneStatusOption = "@ai_pane_status"
+
+// paneEntry is one agent pane of a window, in list-panes order.
+type paneEntry struct {
+	paneID   string
+	status   string // raw status, e.g. "c 💤"
+	eventSig string // completion signature from hooks, logs or notify
+}
+
+// Per-pane unread tracking, keyed by pane id.
+var (
+	paneUnread     = make(map[string]bool)
+	paneWasWorking = make(map[string]bool)
+	paneEventSig   = make(map[string]string)
+	paneSeen       = make(map[string]bool)
+	// paneOption is the @ai_pane_status last written per pane.
+	paneOption = make(map[string]string)
+)
+
+// trackPaneUnread updates and returns the unread state of one pane. A
+// pane turns unread when it stops working or reports a new completion
+// while its window is unfocused; canMark is false while the window is
+// snoozed or fully muted.
+func trackPaneUnread(e paneEntry, focused, canMark bool) bool {
+	id := e.paneID
+	working := isWorkingStatus(e.status)
+	switch {
+	case focused || working:
+		paneUnread[id] = false
+	case !canMark:
+	case paneWasWorking[id]:
+		paneUnread[id] = true
+	case paneSeen[id] && e.eventSig != "" && e.eventSig != paneEventSig[id]:
+		paneUnread[id] = true
+	}
+	paneWasWorking[id] = working
+	paneEventSig[id] = e.eventSig
+	paneSeen[id] = true
+	return paneUnread[id]
+}
+
+// clearPaneUnread marks every agent pane of window read.
+func clearPaneUnread(window string) {
+	for _, pr := range paneReports {
+		if pr.Window == window {
+			paneUnread[pr.PaneID] = false
+		}
+	}
+}
+
+// paneStatusText is a pane's status, marked 📬 when unread.
+func paneStatusText(status string, unread bool) string {
+	if unread {
+		return unreadStatus(status)
+	}
+	return status
+}
+
+// multiPaneStatus joins the compacted status of every pane: "c📬 x🧠".
+func multiPaneStatus(entries []paneEntry, unread func(string) bool) string {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = strings.Replace(paneStatusText(e.status, unread(e.paneID)), " ", "", 1)
+	}
+	return strings.Join(parts, " ")
+}
+
+// paneOptionArgs is the tmux command that sets, or with an empty text
+// unsets, a pane's @ai_pane_status.
+func paneOptionArgs(paneID, text string) []string {
+	if text == "" {
+		return []string{"set-option", "-p", "-u", "-t", paneID, paneStatusOption}
+	}
+	return []string{"set-option", "-p", "-t", paneID, paneStatusOption, text}
+}
+
+// adoptPaneOption records an @ai_pane_status found on a pane that this
+// daemon hasn't written, so that it is updated or unset from then on.
+func adoptPaneOption(paneID, text string) {
+	if _, ok := paneOption[paneID]; ok || paneID == "" || text == "" {
+		return
+	}
+	paneOption[paneID] = text
+}
+
+// setPaneOption writes a pane's @ai_pane_status when it changed.
+func setPaneOption(paneID, text string) {
+	if prev, ok := paneOption[paneID]; ok && prev == text || !ok && text == "" {
+		return
+	}
+	if text == "" {
+		delete(paneOption, paneID)
+	} else {
+		paneOption[paneID] = text
+	}
+	args := paneOptionArgs(paneID, text)
+	if logDryRun {
+		log.Printf("dry-run: would run %s (pane status)", shellQuote(args))
+	}
+	if !dryRun {
+		runTmux(args...)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func resetPaneState(t *testing.T) {
+	t.Helper()
+	paneUnread = make(map[string]bool)
+	paneWasWorking = make(map[string]bool)
+	paneEventSig = make(map[string]string)
+	paneSeen = make(map[string]bool)
+	paneOption = make(map[string]string)
+	t.Cleanup(func() {
+		paneUnread = make(map[string]bool)
+		paneWasWorking = make(map[string]bool)
+		paneEventSig = make(map[string]string)
+		paneSeen = make(map[string]bool)
+		paneOption = make(map[string]string)
+	})
+}
+
+func TestTrackPaneUnread(t *testing.T) {
+	resetPaneState(t)
+	steps := []struct {
+		name    string
+		e       paneEntry
+		focused bool
+		canMark bool
+		want    bool
+	}{
+		{"baseline idle", paneEntry{"%1", "c 💤", "done:1"}, false, true, false},
+		{"same completion", paneEntry{"%1", "c 💤", "done:1"}, false, true, false},
+		{"new completion", paneEntry{"%1", "c 💤", "done:2"}, false, true, true},
+		{"focused clears", paneEntry{"%1", "c 💤", "done:2"}, true, true, false},
+		{"working", paneEntry{"%1", "c 🧠", ""}, false, true, false},
+		{"finished", paneEntry{"%1", "c 💤", ""}, false, true, true},
+		{"stays unread", paneEntry{"%1", "c 💤", ""}, false, true, true},
+		{"working clears", paneEntry{"%1", "c 🧠", ""}, false, true, false},
+		{"snoozed finish", paneEntry{"%1", "c 💤", ""}, false, false, false},
+	}
+	for _, s := range steps {
+		if got := trackPaneUnread(s.e, s.focused, s.canMark); got != s.want {
+			t.Errorf("%s: unread = %v, want %v", s.name, got, s.want)
+		}
+	}
+}
+
+func TestMultiPaneStatus(t *testing.T) {
+	entries := []paneEntry{
+		{paneID: "%1", status: "c 💤"},
+		{paneID: "%2", status: "x 🧠"},
+		{paneID: "%3", status: "c 💤"},
+	}
+	unread := map[string]bool{"%1": true}
+	got := multiPaneStatus(entries, func(id string) bool { return unread[id] })
+	if want := "c📬 x🧠 c💤"; got != want {
+		t.Errorf("multiPaneStatus = %q, want %q", got, want)
+	}
+	if got := paneStatusText("x 🧠", true); got != "x 🧠" {
+		t.Errorf("a working pane is never unread, got %q", got)
+	}
+}
+
+func TestSetPaneOption(t *testing.T) {
+	resetPaneState(t)
+	origRun := runTmux
+	defer func() { runTmux = origRun }()
+	var ran []string
+	runTmux = func(args ...string) error {
+		ran = append(ran, strings.Join(args, " "))
+		return nil
+	}
+
+	setPaneOption("%4", "c 💤")
+	setPaneOption("%4", "c 💤")
+	setPaneOption("%4", "c 📬")
+	setPaneOption("%4", "")
+	setPaneOption("%4", "")
+	setPaneOption("%5", "")
+	want := []string{
+		"set-option -p -t %4 @ai_pane_status c 💤",
+		"set-option -p -t %4 @ai_pane_status c 📬",
+		"set-option -p -u -t %4 @ai_pane_status",
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+}
+
+func TestAdoptPaneOption(t *testing.T) {
+	resetPaneState(t)
+	origRun := runTmux
+	defer func() { runTmux = origRun }()
+	var ran []string
+	runTmux = func(args ...string) error {
+		ran = append(ran, strings.Join(args, " "))
+		return nil
+	}
+
+	setPaneOption("%1", "c 💤")
+	adoptPaneOption("%1", "c 🧠") // ours; tmux output may lag a write
+	adoptPaneOption("%2", "x 📬") // left by a previous daemon
+	adoptPaneOption("%3", "")
+	setPaneOption("%1", "c 💤")
+	setPaneOption("%2", "")
+	setPaneOption("%3", "")
+	want := []string{
+		"set-option -p -t %1 @ai_pane_status c 💤",
+		"set-option -p -u -t %2 @ai_pane_status",
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+}
+
+func TestClearPaneUnread(t *testing.T) {
+	resetPaneState(t)
+	orig := paneReports
+	defer func() { paneReports = orig }()
+	paneReports = []*paneReport{{Window: "w:1", PaneID: "%1"}, {Window: "w:2", PaneID: "%2"}}
+	paneUnread["%1"], paneUnread["%2"] = true, true
+
+	clearPaneUnread("w:1")
+	if paneUnread["%1"] || !paneUnread["%2"] {
+		t.Errorf("paneUnread = %v, want only %%2", paneUnread)
+	}
+}
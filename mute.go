@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Do-not-disturb: a window is muted by its @ai_status_mute tmux option
+// (set by hand or with `ctl mute`) or by cfg.QuietHours. Muted windows
+// keep their icons but send no desktop notifications, pushes or
+// reminders; at level "all" they aren't marked unread either.
+
+const (
+	muteOff    = ""
+	muteNotify = "notify" // no notifications
+	muteAll    = "all"    // no notifications and no unread marking
+
+	muteOption = "@ai_status_mute"
+)
+
+// windowMute is each window's @ai_status_mute level, read every cycle.
+var windowMute = make(map[string]string)
+
+var (
+	listMuteOutput = func() ([]byte, error) {
+		return exec.Command("tmux", "list-windows", "-a",
+			"-F", "#{session_name}:#{window_index} #{"+muteOption+"}").Output()
+	}
+	// setMuteOption sets a window's mute level; muteOff unsets it.
+	setMuteOption = func(window, level string) error {
+		args := []string{"set-window-option", "-t", window, muteOption, level}
+		if level == muteOff {
+			args = []string{"set-window-option", "-u", "-t", window, muteOption}
+		}
+		return exec.Command("tmux", args...).Run()
+	}
+)
+
+// parseMuteLevel accepts "all", anything false-looking as off, and any
+// other value ("on", "1", "notify") as muteNotify.
+func parseMuteLevel(v string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "off", "0", "no", "false":
+		return muteOff, nil
+	case muteAll:
+		return muteAll, nil
+	case "on", "1", "yes", "true", muteNotify:
+		return muteNotify, nil
+	}
+	return muteNotify, fmt.Errorf("unknown mute level %q", v)
+}
+
+func readWindowMutes() map[string]string {
+	mutes := make(map[string]string)
+	out, err := listMuteOutput()
+	if err != nil {
+		return mutes
+	}
+	sc := bufio.NewScanner(strings.NewReader(string(out)))
+	for sc.Scan() {
+		window, value, _ := strings.Cut(sc.Text(), " ")
+		if level, _ := parseMuteLevel(value); level != muteOff {
+			mutes[window] = level
+		}
+	}
+	return mutes
+}
+
+// muteLevel is the stronger of a window's own mute and quiet hours.
+func muteLevel(window string, now time.Time) string {
+	level := windowMute[window]
+	if level == muteAll || !inQuietHours(cfg.QuietHours, now) {
+		return level
+	}
+	if cfg.QuietHoursUnread {
+		return muteAll
+	}
+	return muteNotify
+}
+
+// quietRange is a daily span of quiet hours, e.g. 22:00-07:00. Days, if
+// set, are the days the span starts on.
+type quietRange struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Days []string `json:"days"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func (q quietRange) validate() error {
+	for _, s := range []string{q.From, q.To} {
+		if _, err := clockMinutes(s); err != nil {
+			return fmt.Errorf("quiet_hours: %w", err)
+		}
+	}
+	for _, d := range q.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("quiet_hours: unknown day %q", d)
+		}
+	}
+	return nil
+}
+
+func (q quietRange) onDay(d time.Weekday) bool {
+	if len(q.Days) == 0 {
+		return true
+	}
+	for _, s := range q.Days {
+		if weekdays[strings.ToLower(s)] == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (q quietRange) contains(t time.Time) bool {
+	from, err1 := clockMinutes(q.From)
+	to, err2 := clockMinutes(q.To)
+	if err1 != nil || err2 != nil || from == to {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if from < to {
+		return m >= from && m < to && q.onDay(t.Weekday())
+	}
+	// Overnight: the evening part is on the start day, the morning part
+	// on the day after.
+	if m >= from {
+		return q.onDay(t.Weekday())
+	}
+	return m < to && q.onDay(t.AddDate(0, 0, -1).Weekday())
+}
+
+func inQuietHours(ranges []quietRange, now time.Time) bool {
+	for _, q := range ranges {
+		if q.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// clockMinutes parses "HH:MM" into minutes after midnight.
+func clockMinutes(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hh < 0 || hh > 23 || mm < 0 || mm > 59 {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return hh*60 + mm, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadWindowMutes(t *testing.T) {
+	orig := listMuteOutput
+	defer func() { listMuteOutput = orig }()
+	listMuteOutput = func() ([]byte, error) {
+		return []byte("main:1 \nmain:2 on\nmain:3 all\nmain:4 off\nmain:5 1\n"), nil
+	}
+	got := readWindowMutes()
+	want := map[string]string{"main:2": muteNotify, "main:3": muteAll, "main:5": muteNotify}
+	if len(got) != len(want) {
+		t.Fatalf("mutes = %v, want %v", got, want)
+	}
+	for w, l := range want {
+		if got[w] != l {
+			t.Errorf("%s = %q, want %q", w, got[w], l)
+		}
+	}
+}
+
+func TestQuietRangeContains(t *testing.T) {
+	// 2026-10-19 is a Monday.
+	at := func(day, hh, mm int) time.Time { return time.Date(2026, 10, day, hh, mm, 0, 0, time.Local) }
+	tests := []struct {
+		name string
+		q    quietRange
+		t    time.Time
+		want bool
+	}{
+		{"daytime inside", quietRange{From: "12:00", To: "13:00"}, at(19, 12, 30), true},
+		{"daytime end excluded", quietRange{From: "12:00", To: "13:00"}, at(19, 13, 0), false},
+		{"overnight evening", quietRange{From: "22:00", To: "07:00"}, at(19, 23, 0), true},
+		{"overnight morning", quietRange{From: "22:00", To: "07:00"}, at(20, 6, 59), true},
+		{"overnight outside", quietRange{From: "22:00", To: "07:00"}, at(20, 7, 0), false},
+		{"day matches", quietRange{From: "12:00", To: "13:00", Days: []string{"mon"}}, at(19, 12, 0), true},
+		{"day differs", quietRange{From: "12:00", To: "13:00", Days: []string{"tue"}}, at(19, 12, 0), false},
+		{"overnight morning after start day", quietRange{From: "22:00", To: "07:00", Days: []string{"Sun"}}, at(19, 3, 0), true},
+		{"overnight morning not after start day", quietRange{From: "22:00", To: "07:00", Days: []string{"mon"}}, at(19, 3, 0), false},
+		{"empty span", quietRange{From: "10:00", To: "10:00"}, at(19, 10, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.contains(tt.t); got != tt.want {
+				t.Errorf("contains = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMuteLevel(t *testing.T) {
+	origCfg, origMute := cfg, windowMute
+	defer func() { cfg, windowMute = origCfg, origMute }()
+	cfg = defaultConfig()
+	windowMute = map[string]string{"m:1": muteNotify, "m:2": muteAll}
+	noon := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+
+	if muteLevel("m:0", noon) != muteOff || muteLevel("m:1", noon) != muteNotify {
+		t.Error("outside quiet hours only the window's own level applies")
+	}
+	cfg.QuietHours = []quietRange{{From: "11:00", To: "13:00"}}
+	if muteLevel("m:0", noon) != muteNotify || muteLevel("m:2", noon) != muteAll {
+		t.Error("quiet hours should mute notifications and keep stronger levels")
+	}
+	cfg.QuietHoursUnread = true
+	if muteLevel("m:1", noon) != muteAll {
+		t.Error("quiet_hours_unread should raise the level to all")
+	}
+}
+
+func TestHandleRequest_Mute(t *testing.T) {
+	origSet, origMute := setMuteOption, windowMute
+	defer func() { setMuteOption, windowMute = origSet, origMute }()
+	windowMute = make(map[string]string)
+	set := make(map[string]string)
+	setMuteOption = func(window, level string) error {
+		set[window] = level
+		return nil
+	}
+	seedReports(t, &windowReport{Window: "api:4", Status: "c 💤"})
+	now := time.Now()
+
+	resp := handleRequest(apiRequest{Method: "mute", Window: "api:4"}, now)
+	if r, _ := resp.Result.(*windowReport); !resp.OK || r.Muted != muteNotify || set["api:4"] != muteNotify {
+		t.Fatalf("mute: %+v", resp)
+	}
+	resp = handleRequest(apiRequest{Method: "mute", Window: "api:4", Level: "off"}, now)
+	if r, _ := resp.Result.(*windowReport); !resp.OK || r.Muted != muteOff || set["api:4"] != muteOff || windowMute["api:4"] != "" {
+		t.Fatalf("unmute: %+v", resp)
+	}
+	if resp := handleRequest(apiRequest{Method: "mute", Window: "api:4", Level: "loud"}, now); resp.OK {
+		t.Error("unknown level should fail")
+	}
+}
+
+func TestLoadConfig_QuietHours(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"quiet_hours": [{"from": "22:00", "to": "07:00", "days": ["fri"]}]}`), 0644)
+	if c, err := loadConfig(path); err != nil || len(c.QuietHours) != 1 {
+		t.Fatalf("quiet_hours = %+v, %v", c.QuietHours, err)
+	}
+	for _, bad := range []string{
+		`{"quiet_hours": [{"from": "25:00", "to": "07:00"}]}`,
+		`{"quiet_hours": [{"from": "22:00", "to": "7"}]}`,
+		`{"quiet_hours": [{"from": "22:00", "to": "07:00", "days": ["someday"]}]}`,
+	} {
+		os.WriteFile(path, []byte(bad), 0644)
+		if _, err := loadConfig(path); err == nil {
+			t.Errorf("%s should be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Desktop notifications: when a window turns unread while unfocused, the
+// daemon sends an org.freedesktop.Notifications notification whose
+// actions focus the window. Windows that turn unread within
+// cfg.NotifyCoalesce of each other share one summary notification. They
+// are only sent once the user is idle (see presence.go).
+
+const (
+	notifyDest  = "org.freedesktop.Notifications"
+	notifyPath  = "/org/freedesktop/Notifications"
+	notifyIface = "org.freedesktop.Notifications"
+
+	// notifyMaxActions caps the per-window buttons of a summary.
+	notifyMaxActions = 3
+	// notifyLineMax trims the completion line shown in the body.
+	notifyLineMax = 160
+)
+
+// unreadNotice is one window that just turned unread.
+type unreadNotice struct {
+	window string // "session:index"
+	name   string // window name as rendered, e.g. "c 📬"
+	agent  string
+	line   string // last completion line or agent message
+	// waiting is how long the window has been unread, for reminders.
+	waiting time.Duration
+}
+
+// focusWindow brings a window to the front of the most recent client. It
+// is a var so tests can replace it.
+var focusWindow = func(window string) {
+	exec.Command("tmux", "select-window", "-t", window).Run()
+	exec.Command("tmux", "switch-client", "-t", window).Run()
+}
+
+type notifier struct {
+	mu      sync.Mutex
+	pending []unreadNotice
+	timer   *time.Timer
+	conn    *dbusConn
+	// actions maps notification id -> action key -> window.
+	actions map[uint32]map[string]string
+
+	// dial is a var so tests can point it at a stand-in bus.
+	dial func() (*dbusConn, error)
+}
+
+var desktopNotifier = &notifier{
+	actions: make(map[uint32]map[string]string),
+	dial:    func() (*dbusConn, error) { return dialDBus(sessionBusAddress()) },
+}
+
+// queue records n and schedules a flush after delay; notices arriving
+// before the flush join the same notification.
+func (nt *notifier) queue(n unreadNotice, delay time.Duration) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+	for _, p := range nt.pending {
+		if p.window == n.window {
+			return
+		}
+	}
+	nt.pending = append(nt.pending, n)
+	if nt.timer == nil {
+		nt.timer = time.AfterFunc(delay, nt.flush)
+	}
+}
+
+func (nt *notifier) flush() {
+	nt.mu.Lock()
+	pending := nt.pending
+	nt.pending, nt.timer = nil, nil
+	nt.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	if err := nt.send(pending); err != nil {
+		log.Printf("notify: %v", err)
+	}
+}
+
+func (nt *notifier) send(notices []unreadNotice) error {
+	conn, err := nt.connect()
+	if err != nil {
+		return err
+	}
+	summary, body, actions, targets := notification(notices)
+	hints := map[string]dbusVariant{
+		"desktop-entry": {Sig: "s", Value: "tmux-ai-status"},
+		"category":      {Sig: "s", Value: "im.received"},
+	}
+	reply, err := conn.Call(notifyDest, notifyPath, notifyIface, "Notify", "susssasa{sv}i",
+		"tmux-ai-status", uint32(0), "", summary, body, actions, hints, int32(-1))
+	if err != nil {
+		nt.drop(conn)
+		return err
+	}
+	if len(reply) == 1 {
+		if id, ok := reply[0].(uint32); ok {
+			nt.mu.Lock()
+			nt.actions[id] = targets
+			nt.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// notification renders notices as a summary, body, action list and the
+// window each action focuses.
+func notification(notices []unreadNotice) (summary, body string, actions []string, targets map[string]string) {
+	targets = make(map[string]string)
+	if len(notices) == 1 {
+		n := notices[0]
+		summary = fmt.Sprintf("%s finished in %s", agentLabel(n.agent), n.window)
+		if n.waiting > 0 {
+			summary = fmt.Sprintf("%s waiting in %s for %d min", agentLabel(n.agent), n.window, int(n.waiting.Minutes()))
+		}
+		if n.name != "" {
+			summary += " (" + n.name + ")"
+		}
+		body = n.line
+		actions = []string{"default", "Focus " + n.window}
+		targets["default"] = n.window
+		return summary, body, actions, targets
+	}
+
+	summary = fmt.Sprintf("%d agents finished", len(notices))
+	var lines []string
+	actions = []string{"default", "Focus " + notices[0].window}
+	targets["default"] = notices[0].window
+	for i, n := range notices {
+		line := fmt.Sprintf("%s %s", n.window, agentLabel(n.agent))
+		if n.line != "" {
+			line += ": " + n.line
+		}
+		lines = append(lines, line)
+		if i < notifyMaxActions {
+			key := "focus:" + n.window
+			actions = append(actions, key, n.window)
+			targets[key] = n.window
+		}
+	}
+	return summary, strings.Join(lines, "\n"), actions, targets
+}
+
+func agentLabel(agent string) string {
+	switch agent {
+	case "claude":
+		return "Claude"
+	case "codex":
+		return "Codex"
+	}
+	return "Agent"
+}
+
+// completionLine picks the first non-empty line of an agent message,
+// trimmed for a notification body.
+func completionLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if r := []rune(line); len(r) > notifyLineMax {
+			line = string(r[:notifyLineMax-1]) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// connect returns the bus connection, dialing and subscribing to action
+// signals on first use or after the connection dropped.
+func (nt *notifier) connect() (*dbusConn, error) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+	if nt.conn != nil {
+		return nt.conn, nil
+	}
+	conn, err := nt.dial()
+	if err != nil {
+		return nil, err
+	}
+	rule := "type='signal',interface='" + notifyIface + "'"
+	if err := conn.AddMatch(rule); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	nt.conn = conn
+	go nt.handleSignals(conn)
+	return conn, nil
+}
+
+func (nt *notifier) drop(conn *dbusConn) {
+	conn.Close()
+	nt.mu.Lock()
+	if nt.conn == conn {
+		nt.conn = nil
+	}
+	nt.mu.Unlock()
+}
+
+func (nt *notifier) handleSignals(conn *dbusConn) {
+	for {
+		select {
+		case m := <-conn.Signals:
+			nt.handleSignal(m)
+		case <-conn.Done():
+			nt.drop(conn)
+			return
+		}
+	}
+}
+
+func (nt *notifier) handleSignal(m *dbusMessage) {
+	if m.Interface != notifyIface || len(m.Body) < 2 {
+		return
+	}
+	id, _ := m.Body[0].(uint32)
+	nt.mu.Lock()
+	targets := nt.actions[id]
+	if m.Member == "NotificationClosed" {
+		delete(nt.actions, id)
+	}
+	nt.mu.Unlock()
+	if m.Member != "ActionInvoked" {
+		return
+	}
+	key, _ := m.Body[1].(string)
+	if window, ok := targets[key]; ok {
+		focusWindow(window)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDBusMessage_RoundTrip(t *testing.T) {
+	in := &dbusMessage{
+		Type:        dbusMethodCall,
+		Path:        notifyPath,
+		Interface:   notifyIface,
+		Member:      "Notify",
+		Destination: notifyDest,
+		Signature:   "susssasa{sv}i",
+		Body: []any{"app", uint32(3), "", "summary", "body",
+			[]string{"default", "Focus"},
+			map[string]dbusVariant{"urgency": {Sig: "y", Value: byte(1)}},
+			int32(-1)},
+	}
+	data, err := in.marshal(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := readDBusMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Serial != 9 || out.Path != in.Path || out.Member != "Notify" || out.Destination != notifyDest || out.Signature != in.Signature {
+		t.Errorf("header = %+v", out)
+	}
+	want := []any{"app", uint32(3), "", "summary", "body",
+		[]any{"default", "Focus"},
+		[]any{[]any{"urgency", dbusVariant{Sig: "y", Value: byte(1)}}},
+		int32(-1)}
+	if !reflect.DeepEqual(out.Body, want) {
+		t.Errorf("body = %#v\nwant  %#v", out.Body, want)
+	}
+}
+
+func TestDBusSocket(t *testing.T) {
+	tests := []struct {
+		addr, want string
+		ok         bool
+	}{
+		{"unix:path=/run/user/1000/bus", "/run/user/1000/bus", true},
+		{"unix:path=/tmp/a%20b,guid=1234", "/tmp/a b", true},
+		{"tcp:host=x,port=1;unix:abstract=/tmp/dbus-XYZ,guid=1", "@/tmp/dbus-XYZ", true},
+		{"tcp:host=localhost,port=1234", "", false},
+	}
+	for _, tt := range tests {
+		got, err := dbusSocket(tt.addr)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("dbusSocket(%q) = %q, %v", tt.addr, got, err)
+		}
+	}
+}
+
+func TestNotification(t *testing.T) {
+	summary, body, actions, targets := notification([]unreadNotice{
+		{window: "main:2", name: "c 📬", agent: "claude", line: "All tests pass."},
+	})
+	if summary != "Claude finished in main:2 (c 📬)" || body != "All tests pass." {
+		t.Errorf("single = %q / %q", summary, body)
+	}
+	if !reflect.DeepEqual(actions, []string{"default", "Focus main:2"}) || targets["default"] != "main:2" {
+		t.Errorf("single actions = %q %v", actions, targets)
+	}
+
+	var many []unreadNotice
+	for _, w := range []string{"a:1", "a:2", "a:3", "a:4", "a:5"} {
+		many = append(many, unreadNotice{window: w, agent: "codex", line: "done"})
+	}
+	summary, body, actions, targets = notification(many)
+	if summary != "5 agents finished" || strings.Count(body, "\n") != 4 || !strings.HasPrefix(body, "a:1 Codex: done") {
+		t.Errorf("summary = %q / %q", summary, body)
+	}
+	if len(actions) != 2+2*notifyMaxActions || targets["focus:a:3"] != "a:3" || targets["focus:a:4"] != "" {
+		t.Errorf("summary actions = %q %v", actions, targets)
+	}
+}
+
+func TestCompletionLine(t *testing.T) {
+	if got := completionLine("\n  Fixed the bug.\nDetails follow"); got != "Fixed the bug." {
+		t.Errorf("got %q", got)
+	}
+	long := strings.Repeat("é", notifyLineMax+10)
+	if got := []rune(completionLine(long)); len(got) != notifyLineMax || got[len(got)-1] != '…' {
+		t.Errorf("long line not trimmed: %d runes", len(got))
+	}
+}
+
+// standInBus is a session bus that accepts one client, answers Hello,
+// AddMatch and Notify, and emits ActionInvoked for actionKey shortly
+// after each Notify.
+func standInBus(t *testing.T, actionKey string) (string, chan *dbusMessage) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bus")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	notifies := make(chan *dbusMessage, 8)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if _, err := r.ReadString('\n'); err != nil { // "\0AUTH EXTERNAL ..."
+			return
+		}
+		conn.Write([]byte("OK 0123456789abcdef\r\n"))
+		if line, err := r.ReadString('\n'); err != nil || line != "BEGIN\r\n" {
+			return
+		}
+		var serial uint32
+		send := func(m *dbusMessage) {
+			serial++
+			data, _ := m.marshal(serial)
+			conn.Write(data)
+		}
+		for {
+			m, err := readDBusMessage(r)
+			if err != nil {
+				return
+			}
+			switch m.Member {
+			case "Hello":
+				send(&dbusMessage{Type: dbusMethodReturn, ReplySerial: m.Serial, Signature: "s", Body: []any{":1.7"}})
+			case "AddMatch":
+				send(&dbusMessage{Type: dbusMethodReturn, ReplySerial: m.Serial})
+			case "Notify":
+				notifies <- m
+				send(&dbusMessage{Type: dbusMethodReturn, ReplySerial: m.Serial, Signature: "u", Body: []any{uint32(42)}})
+				time.Sleep(20 * time.Millisecond) // the user reads, then clicks
+				send(&dbusMessage{
+					Type:      dbusSignal,
+					Path:      notifyPath,
+					Interface: notifyIface,
+					Member:    "ActionInvoked",
+					Signature: "us",
+					Body:      []any{uint32(42), actionKey},
+				})
+			}
+		}
+	}()
+	return "unix:path=" + path, notifies
+}
+
+func TestNotifier_StandInBus(t *testing.T) {
+	addr, notifies := standInBus(t, "focus:b:1")
+	focused := make(chan string, 1)
+	origFocus := focusWindow
+	defer func() { focusWindow = origFocus }()
+	focusWindow = func(window string) { focused <- window }
+
+	nt := &notifier{
+		actions: make(map[uint32]map[string]string),
+		dial:    func() (*dbusConn, error) { return dialDBus(addr) },
+	}
+	nt.queue(unreadNotice{window: "a:1", agent: "claude", line: "Done."}, 50*time.Millisecond)
+	nt.queue(unreadNotice{window: "b:1", agent: "codex"}, 50*time.Millisecond)
+	nt.queue(unreadNotice{window: "a:1", agent: "claude"}, 50*time.Millisecond) // duplicate
+
+	select {
+	case m := <-notifies:
+		if m.Signature != "susssasa{sv}i" || m.Body[3] != "2 agents finished" {
+			t.Errorf("Notify = %s %v", m.Signature, m.Body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no Notify call")
+	}
+	select {
+	case m := <-notifies:
+		t.Errorf("burst should be coalesced, got a second Notify: %v", m.Body)
+	case <-time.After(100 * time.Millisecond):
+	}
+	select {
+	case w := <-focused:
+		if w != "b:1" {
+			t.Errorf("focused %q, want b:1", w)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("action did not focus a window")
+	}
+}
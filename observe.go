@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Observe mode: `tmux-ai-status --dry-run` runs the full detection loop
+// but leaves tmux alone, logging each rename it would have made and why.
+// `--table` also redraws a table of every agent window after each cycle.
+
+// logDryRun logs the tmux commands a dry run skips. One-shot passes
+// (status, explain) are dry runs too but stay quiet.
+var logDryRun bool
+
+// shellQuote renders tmux args as a command line that can be pasted.
+func shellQuote(args []string) string {
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, "tmux")
+	for _, a := range args {
+		quoted = append(quoted, shellWord(a))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellWord quotes a for sh when it needs it.
+func shellWord(a string) string {
+	if a == "" || strings.ContainsAny(a, " \t'\"\\$`!*?[]{}()<>|&;#~%") || !isASCII(a) {
+		return "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return a
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// printWindowTable clears the terminal and prints one row per agent window.
+func printWindowTable(w io.Writer, reports map[string]*windowReport, now time.Time) {
+	list := make([]*windowReport, 0, len(reports))
+	for _, r := range reports {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Window < list[j].Window })
+
+	fmt.Fprintf(w, "\033[H\033[2J")
+	fmt.Fprintf(w, "tmux-ai-status --dry-run  %s  (tmux is not modified)\n\n", now.Format(time.TimeOnly))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WINDOW\tPANE\tAGENT\tSTATUS\tNAME\tUNREAD\tFOCUSED")
+	for _, r := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Window, r.PaneID, r.Agent, r.Status, r.Name, yesNo(r.Unread), yesNo(r.Focused))
+	}
+	tw.Flush()
+	if len(list) == 0 {
+		fmt.Fprintln(w, "(no agent windows)")
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTmuxStatusArgs(t *testing.T) {
+	if got := tmuxStatusArgs("main:2", "c 🧠"); !reflect.DeepEqual(got, []string{"rename-window", "-t", "main:2", "c 🧠"}) {
+		t.Errorf("rename = %q", got)
+	}
+	if got := tmuxStatusArgs("main:2", ""); !reflect.DeepEqual(got, []string{"set-option", "-t", "main:2", "automatic-rename", "on"}) {
+		t.Errorf("revert = %q", got)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"rename-window", "-t", "main:2", "c 🧠"}, `tmux rename-window -t main:2 'c 🧠'`},
+		{[]string{"rename-window", "-t", "it's:1", "x"}, `tmux rename-window -t 'it'\''s:1' x`},
+		{[]string{"set-option", "-t", "w:1", "automatic-rename", "on"}, `tmux set-option -t w:1 automatic-rename on`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.args); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPrintWindowTable(t *testing.T) {
+	var buf bytes.Buffer
+	printWindowTable(&buf, map[string]*windowReport{
+		"b:1": {Window: "b:1", PaneID: "%2", Agent: "codex", Status: "x 💤", Name: "x 📬", Unread: true},
+		"a:1": {Window: "a:1", PaneID: "%1", Agent: "claude", Status: "c 🧠", Name: "c 🧠", Focused: true},
+	}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	out := buf.String()
+
+	if !strings.Contains(out, "03:04:05") || !strings.Contains(out, "WINDOW") {
+		t.Errorf("missing header:\n%s", out)
+	}
+	a, b := strings.Index(out, "a:1"), strings.Index(out, "b:1")
+	if a < 0 || b < 0 || a > b {
+		t.Errorf("rows missing or unsorted:\n%s", out)
+	}
+	if !strings.Contains(out, "x 📬") {
+		t.Errorf("rendered name missing:\n%s", out)
+	}
+
+	buf.Reset()
+	printWindowTable(&buf, nil, time.Now())
+	if !strings.Contains(buf.String(), "(no agent windows)") {
+		t.Errorf("empty table:\n%s", buf.String())
+	}
+}
+
+func TestRunDaemon_BadFlags(t *testing.T) {
+	for _, args := range [][]string{{"--table"}, {"--bogus"}} {
+		if code := runDaemon(args); code != 2 {
+			t.Errorf("runDaemon(%q) = %d, want 2", args, code)
+		}
+	}
+}
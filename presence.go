@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Presence: tmux records when each attached client last saw input, which
+// tells us whether the user is at the terminal. Notifications are routed
+// by how long that has been: nothing beyond the status bar while they
+// are typing, desktop notifications once idle for cfg.DesktopIdleAfter,
+// and push targets as well once away for cfg.PushIdleAfter. With no
+// client attached the user counts as away.
+
+var listClientsOutput = func() ([]byte, error) {
+	return exec.Command("tmux", "list-clients", "-F", "#{client_activity} #{client_name}").Output()
+}
+
+type userPresence struct {
+	clients int           // attached clients
+	idle    time.Duration // since the most recent client activity
+}
+
+// presence is the user's presence as of the last cycle. The zero value
+// (no clients) counts as away, so routing fails open.
+var presence userPresence
+
+func readPresence(now time.Time) userPresence {
+	out, err := listClientsOutput()
+	if err != nil {
+		return userPresence{}
+	}
+	return parsePresence(string(out), now)
+}
+
+func parsePresence(out string, now time.Time) userPresence {
+	var p userPresence
+	var latest int64
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		field, _, _ := strings.Cut(line, " ")
+		activity, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		p.clients++
+		latest = max(latest, activity)
+	}
+	if p.clients > 0 {
+		p.idle = max(now.Sub(time.Unix(latest, 0)), 0)
+	}
+	return p
+}
+
+func (p userPresence) idleFor(d time.Duration) bool {
+	return p.clients == 0 || p.idle >= d
+}
+
+// wantsDesktop reports whether desktop notifications should be sent.
+func (p userPresence) wantsDesktop() bool {
+	return p.idleFor(cfg.DesktopIdleAfter.Duration)
+}
+
+// wantsPush reports whether push targets should be used.
+func (p userPresence) wantsPush() bool {
+	return p.idleFor(cfg.PushIdleAfter.Duration)
+}
+
+func (p userPresence) String() string {
+	if p.clients == 0 {
+		return "no client attached"
+	}
+	return "idle " + p.idle.Round(time.Second).String()
+}
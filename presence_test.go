@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePresence(t *testing.T) {
+	now := time.Unix(1_700_000_600, 0)
+	tests := []struct {
+		name    string
+		out     string
+		clients int
+		idle    time.Duration
+	}{
+		{"no clients", "", 0, 0},
+		{"one client", "1700000540 /dev/pts/1\n", 1, time.Minute},
+		{"most recent wins", "1700000000 /dev/pts/1\n1700000590 /dev/pts/2\n", 2, 10 * time.Second},
+		{"clock skew", "1700000700 /dev/pts/1\n", 1, 0},
+		{"garbage skipped", "x /dev/pts/1\n1700000300 /dev/pts/2\n", 1, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parsePresence(tt.out, now)
+			if p.clients != tt.clients || p.idle != tt.idle {
+				t.Errorf("got %d clients idle %v, want %d idle %v", p.clients, p.idle, tt.clients, tt.idle)
+			}
+		})
+	}
+}
+
+func TestPresenceRouting(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg = defaultConfig()
+
+	tests := []struct {
+		name          string
+		p             userPresence
+		desktop, push bool
+	}{
+		{"typing", userPresence{clients: 1, idle: 5 * time.Second}, false, false},
+		{"idle", userPresence{clients: 1, idle: 3 * time.Minute}, true, false},
+		{"away", userPresence{clients: 2, idle: 15 * time.Minute}, true, true},
+		{"detached", userPresence{}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.wantsDesktop(); got != tt.desktop {
+				t.Errorf("wantsDesktop = %v, want %v", got, tt.desktop)
+			}
+			if got := tt.p.wantsPush(); got != tt.push {
+				t.Errorf("wantsPush = %v, want %v", got, tt.push)
+			}
+		})
+	}
+
+	cfg.PushIdleAfter = duration{}
+	if !(userPresence{clients: 1}).wantsPush() {
+		t.Error(`"0s" threshold should always push`)
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP push notifications: each entry of cfg.Push receives the status
+// events whose reason is in its "on" list (default: unread), rendered for
+// a generic JSON webhook, an ntfy topic or a Slack-compatible incoming
+// webhook, while the user is away (see presence.go). Failed deliveries
+// are retried with backoff; a target gets at most one push per
+// min_interval so a flapping window can't flood a phone. Events that come
+// too soon are held and folded into the next push.
+
+const (
+	pushTimeout     = 10 * time.Second
+	pushQueueLen    = 8
+	defaultRetries  = 2
+	defaultInterval = 10 * time.Second
+
+	defaultPushTitle   = "{agent} in {window}"
+	defaultPushMessage = "{new_status} {message}"
+)
+
+// pushBackoff is the delay before the first retry; it doubles after each.
+// A var so tests don't sleep.
+var pushBackoff = time.Second
+
+type pushTarget struct {
+	Name string `json:"name"`
+	// Type is "webhook", "ntfy" or "slack".
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	// On lists the event reasons that trigger a push (see the event
+	// stream); default ["unread"].
+	On []string `json:"on"`
+	// Title and Message are templates for ntfy and slack.
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// Template is the webhook body; default the event as JSON. Values
+	// are JSON-escaped, so it can be written as "{\"text\": \"{window}\"}".
+	Template string            `json:"template"`
+	Headers  map[string]string `json:"headers"`
+	// Token is sent as "Authorization: Bearer" (ntfy access tokens).
+	Token       string    `json:"token"`
+	Retries     *int      `json:"retries"`
+	MinInterval *duration `json:"min_interval"`
+}
+
+func (t pushTarget) label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Type + " " + t.URL
+}
+
+func (t pushTarget) retries() int {
+	if t.Retries == nil {
+		return defaultRetries
+	}
+	return max(*t.Retries, 0)
+}
+
+func (t pushTarget) minInterval() time.Duration {
+	if t.MinInterval == nil {
+		return defaultInterval
+	}
+	return t.MinInterval.Duration
+}
+
+func (t pushTarget) wants(reason string) bool {
+	if len(t.On) == 0 {
+		return reason == "unread"
+	}
+	for _, r := range t.On {
+		if r == reason || r == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (t pushTarget) validate() error {
+	switch t.Type {
+	case "webhook", "ntfy", "slack":
+	default:
+		return fmt.Errorf("push target %q: type must be webhook, ntfy or slack", t.label())
+	}
+	if !strings.HasPrefix(t.URL, "http://") && !strings.HasPrefix(t.URL, "https://") {
+		return fmt.Errorf("push target %q: url must be http(s)", t.label())
+	}
+	return nil
+}
+
+// renderPush fills {placeholders} from ev; with jsonSafe the values are
+// escaped for use inside JSON strings.
+func renderPush(tmpl string, ev statusEvent, jsonSafe bool) string {
+	esc := func(s string) string {
+		if !jsonSafe {
+			return s
+		}
+		b, _ := json.Marshal(s)
+		return string(b[1 : len(b)-1])
+	}
+	return strings.NewReplacer(
+		"{window}", esc(ev.Window),
+		"{session}", esc(windowSession(ev.Window)),
+		"{pane_id}", esc(ev.PaneID),
+		"{agent}", esc(agentLabel(ev.Agent)),
+		"{old_status}", esc(ev.OldStatus),
+		"{new_status}", esc(ev.NewStatus),
+		"{status}", esc(ev.NewStatus),
+		"{reason}", esc(ev.Reason),
+		"{message}", esc(ev.Message),
+		"{unread}", strconv.FormatBool(ev.Unread),
+		"{at}", ev.At.Format(time.RFC3339),
+	).Replace(tmpl)
+}
+
+// pushRequest builds the HTTP request for one delivery attempt.
+func pushRequest(t pushTarget, ev statusEvent) (*http.Request, error) {
+	title := t.Title
+	if title == "" {
+		title = defaultPushTitle
+	}
+	message := t.Message
+	if message == "" {
+		message = defaultPushMessage
+	}
+
+	var body []byte
+	contentType := "application/json"
+	switch t.Type {
+	case "webhook":
+		if t.Template == "" {
+			body, _ = json.Marshal(ev)
+		} else {
+			body = []byte(renderPush(t.Template, ev, true))
+		}
+	case "slack":
+		text := "*" + renderPush(title, ev, false) + "*\n" + renderPush(message, ev, false) + coalescedNote(ev)
+		body, _ = json.Marshal(map[string]string{"text": text})
+	case "ntfy":
+		body = []byte(strings.TrimSpace(renderPush(message, ev, false) + coalescedNote(ev)))
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if t.Type == "ntfy" {
+		req.Header.Set("Title", renderPush(title, ev, false))
+		req.Header.Set("Tags", "robot")
+	}
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// coalescedNote mentions the held events folded into a push.
+func coalescedNote(ev statusEvent) string {
+	if len(ev.Coalesced) == 0 {
+		return ""
+	}
+	var windows []string
+	for _, w := range ev.Coalesced {
+		if !slices.Contains(windows, w) {
+			windows = append(windows, w)
+		}
+	}
+	return fmt.Sprintf("\n+%d earlier (%s)", len(ev.Coalesced), strings.Join(windows, ", "))
+}
+
+var pushClient = &http.Client{Timeout: pushTimeout}
+
+// deliver sends ev to t, retrying network errors, 429 and 5xx.
+func deliver(t pushTarget, ev statusEvent) error {
+	var err error
+	wait := pushBackoff
+	for attempt := 0; attempt <= t.retries(); attempt++ {
+		if attempt > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		var req *http.Request
+		if req, err = pushRequest(t, ev); err != nil {
+			return err
+		}
+		var resp *http.Response
+		resp, err = pushClient.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		err = fmt.Errorf("%s", resp.Status)
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return err // our request is wrong; retrying won't help
+		}
+	}
+	return err
+}
+
+type pushJob struct {
+	target pushTarget
+	ev     statusEvent
+}
+
+// pusher fans events out to one worker per target, so a slow or dead
+// endpoint only delays its own pushes.
+type pusher struct {
+	mu      sync.Mutex
+	last    map[string]time.Time
+	held    map[string]*heldPush
+	workers map[string]chan pushJob
+}
+
+// heldPush is a rate-limited event waiting for its target's min_interval
+// to pass.
+type heldPush struct {
+	ev    statusEvent
+	timer *time.Timer
+}
+
+func newPusher() *pusher {
+	return &pusher{
+		last:    make(map[string]time.Time),
+		held:    make(map[string]*heldPush),
+		workers: make(map[string]chan pushJob),
+	}
+}
+
+// run pushes the events of sub until it is unsubscribed.
+func (p *pusher) run(sub *subscriber) {
+	for ev := range sub.ch {
+		daemonMu.Lock()
+		targets := cfg.Push
+		away := presence.wantsPush()
+		daemonMu.Unlock()
+		if !away || ev.Muted {
+			continue
+		}
+		for _, t := range targets {
+			if t.wants(ev.Reason) {
+				p.dispatch(t, ev, time.Now())
+			}
+		}
+	}
+}
+
+// dispatch sends ev to t, or holds it until t's min_interval has passed.
+// A held event is folded into the next one: only the newest is sent, and
+// it lists the windows of those before it in Coalesced.
+func (p *pusher) dispatch(t pushTarget, ev statusEvent, now time.Time) {
+	key := t.Type + " " + t.URL + " " + t.Name
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if h := p.held[key]; h != nil {
+		h.timer.Stop()
+		delete(p.held, key)
+		ev.Coalesced = append(append(h.ev.Coalesced, h.ev.Window), ev.Coalesced...)
+	}
+	if last, ok := p.last[key]; ok && now.Sub(last) < t.minInterval() {
+		wait := t.minInterval() - now.Sub(last)
+		log.Printf("push %s: rate limited, holding %s %s for %s", t.label(), ev.Window, ev.Reason, wait.Round(time.Second))
+		h := &heldPush{ev: ev}
+		h.timer = time.AfterFunc(wait, func() { p.release(t, key, h) })
+		p.held[key] = h
+		return
+	}
+	p.send(t, key, ev, now)
+}
+
+// release sends a held event once its wait is over, unless a newer event
+// has taken it along already.
+func (p *pusher) release(t pushTarget, key string, h *heldPush) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.held[key] != h {
+		return
+	}
+	delete(p.held, key)
+	p.send(t, key, h.ev, time.Now())
+}
+
+// send queues ev for t's worker; p.mu must be held.
+func (p *pusher) send(t pushTarget, key string, ev statusEvent, now time.Time) {
+	p.last[key] = now
+
+	q, ok := p.workers[key]
+	if !ok {
+		q = make(chan pushJob, pushQueueLen)
+		p.workers[key] = q
+		go func() {
+			for job := range q {
+				if err := deliver(job.target, job.ev); err != nil {
+					log.Printf("push %s: %v", job.target.label(), err)
+				}
+			}
+		}()
+	}
+	select {
+	case q <- pushJob{target: t, ev: ev}:
+	default:
+		log.Printf("push %s: queue full, dropping %s %s", t.label(), ev.Window, ev.Reason)
+	}
+}
+
+// runPushTest sends a sample event to every push target, or the one named.
+func runPushTest(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "usage: tmux-ai-status push-test [NAME]\n")
+		return 2
+	}
+	c, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status push-test: %v\n", err)
+		return 1
+	}
+	ev := statusEvent{
+		Window:    "main:1",
+		PaneID:    "%1",
+		Agent:     "claude",
+		OldStatus: "c 🧠",
+		NewStatus: "c 📬",
+		Unread:    true,
+		Reason:    "unread",
+		Message:   "Test notification from tmux-ai-status",
+		At:        time.Now(),
+	}
+	if err := pushTest(os.Stdout, c.Push, args, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status push-test: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func pushTest(w io.Writer, targets []pushTarget, args []string, ev statusEvent) error {
+	sent, failed := 0, 0
+	for _, t := range targets {
+		if len(args) == 1 && t.Name != args[0] {
+			continue
+		}
+		sent++
+		if err := deliver(t, ev); err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %v\n", t.label(), err)
+			continue
+		}
+		fmt.Fprintf(w, "ok   %s\n", t.label())
+	}
+	switch {
+	case sent == 0 && len(args) == 1:
+		return fmt.Errorf("no push target named %q", args[0])
+	case sent == 0:
+		return errors.New("no push targets configured")
+	case failed > 0:
+		return fmt.Errorf("%d of %d targets failed", failed, sent)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var sampleEvent = statusEvent{
+	Window:    "work:3",
+	PaneID:    "%8",
+	Agent:     "codex",
+	OldStatus: "x 🧠",
+	NewStatus: "x 📬",
+	Unread:    true,
+	Reason:    "unread",
+	Message:   `Added "retry" support`,
+	At:        time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+}
+
+type capturedPush struct {
+	header http.Header
+	body   string
+}
+
+// pushStandIn records requests and answers with the given status codes in
+// turn (200 once they run out).
+func pushStandIn(t *testing.T, codes ...int) (*httptest.Server, func() []capturedPush) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []capturedPush
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, capturedPush{header: r.Header.Clone(), body: string(body)})
+		code := http.StatusOK
+		if len(got) <= len(codes) {
+			code = codes[len(got)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []capturedPush {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedPush(nil), got...)
+	}
+}
+
+func noBackoff(t *testing.T) {
+	orig := pushBackoff
+	pushBackoff = time.Millisecond
+	t.Cleanup(func() { pushBackoff = orig })
+}
+
+func TestRenderPush(t *testing.T) {
+	got := renderPush(`{"text": "{agent} {window}: {message}"}`, sampleEvent, true)
+	var v map[string]string
+	if err := json.Unmarshal([]byte(got), &v); err != nil {
+		t.Fatalf("rendered template is not JSON: %s", got)
+	}
+	if v["text"] != `Codex work:3: Added "retry" support` {
+		t.Errorf("text = %q", v["text"])
+	}
+	if got := renderPush("{session} {reason} {unread} {at}", sampleEvent, false); got != "work unread true 2026-10-18T09:30:00Z" {
+		t.Errorf("plain = %q", got)
+	}
+}
+
+func TestDeliver_Formats(t *testing.T) {
+	srv, got := pushStandIn(t)
+	targets := []pushTarget{
+		{Type: "webhook", URL: srv.URL, Headers: map[string]string{"X-Key": "k"}},
+		{Type: "webhook", URL: srv.URL, Template: `{"w": "{window}"}`},
+		{Type: "ntfy", URL: srv.URL, Token: "tk_1"},
+		{Type: "slack", URL: srv.URL, Title: "{window} done"},
+	}
+	for _, tgt := range targets {
+		if err := deliver(tgt, sampleEvent); err != nil {
+			t.Fatalf("%s: %v", tgt.label(), err)
+		}
+	}
+	reqs := got()
+	if len(reqs) != 4 {
+		t.Fatalf("got %d requests", len(reqs))
+	}
+
+	var ev statusEvent
+	if err := json.Unmarshal([]byte(reqs[0].body), &ev); err != nil || ev.Window != "work:3" || reqs[0].header.Get("X-Key") != "k" {
+		t.Errorf("default webhook = %s %v", reqs[0].body, reqs[0].header)
+	}
+	if reqs[1].body != `{"w": "work:3"}` {
+		t.Errorf("templated webhook = %s", reqs[1].body)
+	}
+	if reqs[2].body != `x 📬 Added "retry" support` || reqs[2].header.Get("Title") != "Codex in work:3" ||
+		reqs[2].header.Get("Authorization") != "Bearer tk_1" || !strings.HasPrefix(reqs[2].header.Get("Content-Type"), "text/plain") {
+		t.Errorf("ntfy = %s %v", reqs[2].body, reqs[2].header)
+	}
+	var slack map[string]string
+	if json.Unmarshal([]byte(reqs[3].body), &slack) != nil || !strings.HasPrefix(slack["text"], "*work:3 done*\nx 📬") {
+		t.Errorf("slack = %s", reqs[3].body)
+	}
+}
+
+func TestDeliver_Retries(t *testing.T) {
+	noBackoff(t)
+
+	srv, got := pushStandIn(t, 503, 429)
+	if err := deliver(pushTarget{Type: "slack", URL: srv.URL}, sampleEvent); err != nil {
+		t.Errorf("should succeed on the third attempt: %v", err)
+	}
+	if n := len(got()); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+
+	srv, got = pushStandIn(t, 500, 500, 500, 500)
+	if err := deliver(pushTarget{Type: "slack", URL: srv.URL}, sampleEvent); err == nil {
+		t.Error("expected failure after retries")
+	}
+	if n := len(got()); n != 1+defaultRetries {
+		t.Errorf("attempts = %d, want %d", n, 1+defaultRetries)
+	}
+
+	srv, got = pushStandIn(t, 404)
+	if err := deliver(pushTarget{Type: "slack", URL: srv.URL}, sampleEvent); err == nil {
+		t.Error("expected failure on 404")
+	}
+	if n := len(got()); n != 1 {
+		t.Errorf("4xx should not be retried, got %d attempts", n)
+	}
+}
+
+func TestPusher_FiltersAndRateLimits(t *testing.T) {
+	srv, got := pushStandIn(t)
+	interval := duration{time.Minute}
+	target := pushTarget{Type: "webhook", URL: srv.URL, MinInterval: &interval}
+	p := newPusher()
+	now := time.Now()
+
+	if target.wants("read") || !target.wants("unread") {
+		t.Error("default trigger should be unread only")
+	}
+	p.dispatch(target, sampleEvent, now)
+	p.dispatch(target, sampleEvent, now.Add(30*time.Second)) // held
+	p.dispatch(target, sampleEvent, now.Add(61*time.Second)) // takes it along
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(got()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	pushes := got()
+	if n := len(pushes); n != 2 {
+		t.Fatalf("deliveries = %d, want 2", n)
+	}
+	var ev statusEvent
+	json.Unmarshal([]byte(pushes[1].body), &ev)
+	if len(ev.Coalesced) != 1 || ev.Coalesced[0] != sampleEvent.Window {
+		t.Errorf("second push should carry the held event, coalesced = %v", ev.Coalesced)
+	}
+
+	all := pushTarget{On: []string{"*"}}
+	if !all.wants("exit") {
+		t.Error(`"*" should match every reason`)
+	}
+}
+
+func TestPusher_ReleasesHeldEvent(t *testing.T) {
+	srv, got := pushStandIn(t)
+	interval := duration{50 * time.Millisecond}
+	target := pushTarget{Type: "ntfy", URL: srv.URL, MinInterval: &interval}
+	p := newPusher()
+
+	first, second, third := sampleEvent, sampleEvent, sampleEvent
+	second.Window, second.Message = "work:4", "first held"
+	third.Window, third.Message = "work:5", "second held"
+	p.dispatch(target, first, time.Now())
+	p.dispatch(target, second, time.Now())
+	p.dispatch(target, third, time.Now())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(got()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	pushes := got()
+	if len(pushes) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(pushes))
+	}
+	if body := pushes[1].body; !strings.Contains(body, "second held") || !strings.Contains(body, "+1 earlier (work:4)") {
+		t.Errorf("held push body = %q", body)
+	}
+}
+
+func TestPushTest(t *testing.T) {
+	noBackoff(t)
+	ok, _ := pushStandIn(t)
+	bad, _ := pushStandIn(t, 400)
+	targets := []pushTarget{
+		{Name: "phone", Type: "ntfy", URL: ok.URL},
+		{Name: "broken", Type: "webhook", URL: bad.URL},
+	}
+	var out bytes.Buffer
+	if err := pushTest(&out, targets, []string{"phone"}, sampleEvent); err != nil {
+		t.Errorf("phone: %v", err)
+	}
+	if err := pushTest(&out, targets, nil, sampleEvent); err == nil || !strings.Contains(out.String(), "FAIL broken") {
+		t.Errorf("expected broken target to fail: %v\n%s", err, out.String())
+	}
+	if err := pushTest(&out, targets, []string{"nope"}, sampleEvent); err == nil {
+		t.Error("unknown name should fail")
+	}
+	if err := pushTest(&out, nil, nil, sampleEvent); err == nil {
+		t.Error("no targets should fail")
+	}
+}
+
+func TestLoadConfig_Push(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"push": [{"type": "ntfy", "url": "https://ntfy.sh/t", "retries": 0, "min_interval": "1m"}]}`), 0644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Push) != 1 || c.Push[0].retries() != 0 || c.Push[0].minInterval() != time.Minute {
+		t.Errorf("push = %+v", c.Push)
+	}
+
+	os.WriteFile(path, []byte(`{"push": [{"type": "pager", "url": "https://x"}]}`), 0644)
+	if _, err := loadConfig(path); err == nil {
+		t.Error("unknown type should be rejected")
+	}
+	os.WriteFile(path, []byte(`{"push": [{"type": "slack", "url": "hooks.slack.com"}]}`), 0644)
+	if _, err := loadConfig(path); err == nil {
+		t.Error("url without scheme should be rejected")
+	}
+}
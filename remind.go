@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"time"
+)
+
+// Reminders: a window left 📬 is re-notified at each delay in
+// cfg.Remind.After (counted from when it turned unread) and, after
+// cfg.Remind.EscalateAfter, its tab is restyled with cfg.Remind.Style.
+// Both stop as soon as the window is read, by focus or by the agent
+// working again. Restyled windows carry remindStyledOption, so a new
+// daemon clears only styles an earlier one left behind.
+
+type remindConfig struct {
+	// After lists delays since the window turned unread; each sends one
+	// reminder.
+	After []duration `json:"after"`
+	// EscalateAfter restyles the tab once the window has been unread
+	// this long; zero never does.
+	EscalateAfter duration `json:"escalate_after"`
+	// Style is the tmux window-status-style of an escalated tab.
+	Style string `json:"style"`
+}
+
+func (r remindConfig) validate() error {
+	var prev time.Duration
+	for _, d := range r.After {
+		if d.Duration <= prev {
+			return fmt.Errorf("remind: after must be increasing positive delays, got %s after %s", d, duration{prev})
+		}
+		prev = d.Duration
+	}
+	if r.EscalateAfter.Duration < 0 {
+		return fmt.Errorf("remind: negative escalate_after %s", r.EscalateAfter)
+	}
+	return nil
+}
+
+// remindStyledOption marks a window whose window-status-style was set
+// by a reminder.
+const remindStyledOption = "@ai_remind_styled"
+
+// windowRemind tracks windows that are unread.
+var windowRemind = make(map[string]*remindState)
+
+type remindState struct {
+	since     time.Time // when the window turned unread
+	sent      int       // reminders sent, an index into cfg.Remind.After
+	escalated bool
+}
+
+// remindAction is what trackReminder wants done to a window this cycle.
+type remindAction struct {
+	remind   bool
+	waiting  time.Duration
+	escalate bool // apply cfg.Remind.Style
+	restore  bool // drop the escalated style
+}
+
+// trackReminder advances the reminder state of window. Reminders that
+// came due within a single cycle (a slow cycle, a suspended laptop) are
+// folded into one.
+func trackReminder(window string, unread bool, now time.Time) remindAction {
+	var act remindAction
+	st := windowRemind[window]
+	if !unread {
+		if st != nil {
+			act.restore = st.escalated
+			delete(windowRemind, window)
+		}
+		return act
+	}
+	if st == nil {
+		st = &remindState{since: now}
+		windowRemind[window] = st
+	}
+	act.waiting = now.Sub(st.since)
+	for st.sent < len(cfg.Remind.After) && act.waiting >= cfg.Remind.After[st.sent].Duration {
+		st.sent++
+		act.remind = true
+	}
+	if !st.escalated && cfg.Remind.EscalateAfter.Duration > 0 && act.waiting >= cfg.Remind.EscalateAfter.Duration {
+		st.escalated = true
+		act.escalate = true
+	}
+	return act
+}
+
+// tmuxStyleArgs is the tmux command that sets, or with an empty style
+// unsets, a window's tab style together with remindStyledOption.
+func tmuxStyleArgs(window, style string) []string {
+	if style == "" {
+		return []string{
+			"set-window-option", "-u", "-t", window, "window-status-style", ";",
+			"set-window-option", "-u", "-t", window, remindStyledOption,
+		}
+	}
+	return []string{
+		"set-window-option", "-t", window, "window-status-style", style, ";",
+		"set-window-option", "-t", window, remindStyledOption, "1",
+	}
+}
+
+func setWindowStyle(window, style string) {
+	args := tmuxStyleArgs(window, style)
+	if logDryRun {
+		log.Printf("dry-run: would run %s (remind)", shellQuote(args))
+	}
+	if !dryRun {
+		exec.Command("tmux", args...).Run()
+	}
+}
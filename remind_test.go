@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrackReminder(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg; windowRemind = make(map[string]*remindState) }()
+	cfg = defaultConfig()
+	cfg.Remind.After = []duration{{15 * time.Minute}, {time.Hour}}
+	cfg.Remind.EscalateAfter = duration{30 * time.Minute}
+	windowRemind = make(map[string]*remindState)
+
+	t0 := time.Now()
+	steps := []struct {
+		at     time.Duration
+		unread bool
+		want   remindAction
+	}{
+		{0, true, remindAction{}},
+		{10 * time.Minute, true, remindAction{waiting: 10 * time.Minute}},
+		{15 * time.Minute, true, remindAction{remind: true, waiting: 15 * time.Minute}},
+		{20 * time.Minute, true, remindAction{waiting: 20 * time.Minute}},
+		{30 * time.Minute, true, remindAction{waiting: 30 * time.Minute, escalate: true}},
+		{2 * time.Hour, true, remindAction{remind: true, waiting: 2 * time.Hour}},
+		{3 * time.Hour, true, remindAction{waiting: 3 * time.Hour}},
+		{3*time.Hour + time.Second, false, remindAction{restore: true}},
+		{4 * time.Hour, false, remindAction{}},
+		// Unread again: the clock restarts.
+		{5 * time.Hour, true, remindAction{}},
+		{5*time.Hour + 15*time.Minute, true, remindAction{remind: true, waiting: 15 * time.Minute}},
+	}
+	for _, s := range steps {
+		if got := trackReminder("s:1", s.unread, t0.Add(s.at)); !reflect.DeepEqual(got, s.want) {
+			t.Errorf("at %s unread %v: got %+v, want %+v", s.at, s.unread, got, s.want)
+		}
+	}
+}
+
+func TestTrackReminder_MissedRemindersFold(t *testing.T) {
+	origCfg := cfg
+	defer func() { cfg = origCfg; windowRemind = make(map[string]*remindState) }()
+	cfg = defaultConfig()
+	cfg.Remind.After = []duration{{time.Minute}, {2 * time.Minute}, {3 * time.Minute}}
+	windowRemind = make(map[string]*remindState)
+
+	t0 := time.Now()
+	trackReminder("s:1", true, t0)
+	if got := trackReminder("s:1", true, t0.Add(time.Hour)); !got.remind {
+		t.Errorf("got %+v, want one reminder", got)
+	}
+	if got := trackReminder("s:1", true, t0.Add(2*time.Hour)); got.remind {
+		t.Errorf("got %+v, want reminders exhausted", got)
+	}
+}
+
+func TestTmuxStyleArgs(t *testing.T) {
+	if got := strings.Join(tmuxStyleArgs("s:1", "bg=red"), " "); got != "set-window-option -t s:1 window-status-style bg=red ; set-window-option -t s:1 @ai_remind_styled 1" {
+		t.Errorf("set: %s", got)
+	}
+	if got := strings.Join(tmuxStyleArgs("s:1", ""), " "); got != "set-window-option -u -t s:1 window-status-style ; set-window-option -u -t s:1 @ai_remind_styled" {
+		t.Errorf("unset: %s", got)
+	}
+}
+
+func TestReminderNotification(t *testing.T) {
+	summary, _, _, _ := notification([]unreadNotice{{window: "main:2", agent: "claude", waiting: 15*time.Minute + 20*time.Second}})
+	if summary != "Claude waiting in main:2 for 15 min" {
+		t.Errorf("summary = %q", summary)
+	}
+}
+
+func TestLoadConfig_Remind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"remind": {"after": ["15m", "1h"], "escalate_after": "30m"}}`), 0644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Remind.After) != 2 || c.Remind.EscalateAfter.Duration != 30*time.Minute || c.Remind.Style != defaultConfig().Remind.Style {
+		t.Errorf("remind = %+v", c.Remind)
+	}
+
+	os.WriteFile(path, []byte(`{"remind": {"after": ["1h", "15m"]}}`), 0644)
+	if _, err := loadConfig(path); err == nil {
+		t.Error("decreasing delays should be rejected")
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Codex rollout logs: Codex records each session as JSONL under
+// ~/.codex/sessions/YYYY/MM/DD/rollout-<time>-<id>.jsonl. The first line
+// is session_meta (id, start time, cwd); later event_msg records carry
+// task_started, task_complete, turn_aborted and exec begin/end events.
+
+// codexSessionsDir is a var so tests can point it at fixtures.
+var codexSessionsDir = func() string {
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return filepath.Join(dir, "sessions")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".codex", "sessions")
+}
+
+var (
+	// paneRollout follows the rollout of the Codex agent in each pane, by
+	// pane id.
+	paneRollout = make(map[string]*rolloutTail)
+	// rolloutMetaCache avoids re-reading the first line of every candidate.
+	rolloutMetaCache = make(map[string]rolloutMeta)
+	// rolloutCandidates are the paths read this cycle; the cache keeps
+	// only those.
+	rolloutCandidates = make(map[string]bool)
+)
+
+const (
+	// rolloutMatchSlack allows for the rollout being created just before
+	// /proc's coarse process start time.
+	rolloutMatchSlack = 10 * time.Second
+	// rolloutWorkingMaxAge is how long a rollout that last said "working"
+	// is believed over the pane, like transcriptWorkingMaxAge.
+	rolloutWorkingMaxAge = 10 * time.Minute
+)
+
+type rolloutMeta struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Cwd       string    `json:"cwd"`
+}
+
+type rolloutState struct {
+	phase    string // phaseWorking, phaseWaiting, phaseInterrupted; "" unknown
+	command  string // most recently started exec command, "" when none
+	execs    []rolloutExec
+	lastTurn string    // timestamp of the last task_complete/turn_aborted
+	at       time.Time // timestamp of the last record
+}
+
+// rolloutExec is a command between exec_command_begin and its end.
+type rolloutExec struct {
+	callID  string
+	command string
+}
+
+type rolloutTail struct {
+	jsonlTail
+	pid   int
+	state rolloutState
+}
+
+type rolloutRecord struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type rolloutPayload struct {
+	Type      string          `json:"type"`
+	CallID    string          `json:"call_id"`
+	Command   json.RawMessage `json:"command"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+}
+
+// findCodexRollout matches a codex process to its rollout file: same cwd,
+// written since the process started, preferring the session whose
+// recorded start is closest to the process start.
+func findCodexRollout(agentPID int, now time.Time) string {
+	cwd := readCwd(agentPID)
+	started := procStartTime(agentPID)
+	if cwd == "" || started.IsZero() {
+		return ""
+	}
+	root := codexSessionsDir()
+	var best string
+	var bestScore time.Duration
+	for day := started.AddDate(0, 0, -1); !day.After(now.AddDate(0, 0, 1)); day = day.AddDate(0, 0, 1) {
+		dir := filepath.Join(root, day.Format("2006"), day.Format("01"), day.Format("02"))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), "rollout-") || !strings.HasSuffix(e.Name(), ".jsonl") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || info.ModTime().Before(started.Add(-rolloutMatchSlack)) {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			meta, ok := readRolloutMeta(path)
+			if !ok || meta.Cwd != cwd {
+				continue
+			}
+			// Sessions started with the process score by distance from its
+			// start; resumed (older) sessions rank after them.
+			score := meta.Timestamp.Sub(started)
+			if score < -rolloutMatchSlack {
+				score = 365*24*time.Hour + now.Sub(info.ModTime())
+			} else if score < 0 {
+				score = -score
+			}
+			if best == "" || score < bestScore {
+				best, bestScore = path, score
+			}
+		}
+	}
+	return best
+}
+
+func readRolloutMeta(path string) (rolloutMeta, bool) {
+	rolloutCandidates[path] = true
+	if meta, ok := rolloutMetaCache[path]; ok {
+		return meta, true
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return rolloutMeta{}, false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	if !sc.Scan() {
+		return rolloutMeta{}, false
+	}
+	var r rolloutRecord
+	var meta rolloutMeta
+	if json.Unmarshal(sc.Bytes(), &r) != nil || r.Type != "session_meta" ||
+		json.Unmarshal(r.Payload, &meta) != nil {
+		return rolloutMeta{}, false
+	}
+	rolloutMetaCache[path] = meta
+	return meta, true
+}
+
+// pruneRolloutMetaCache drops cached metadata of files that were not
+// candidates this cycle.
+func pruneRolloutMetaCache() {
+	for path := range rolloutMetaCache {
+		if !rolloutCandidates[path] {
+			delete(rolloutMetaCache, path)
+		}
+	}
+	clear(rolloutCandidates)
+}
+
+// pollRollout follows the rollout of a pane's codex agent and returns
+// its state, or nil when no rollout was found.
+func pollRollout(paneID string, agentPID int, now time.Time) *rolloutState {
+	t, ok := paneRollout[paneID]
+	if !ok || t.pid != agentPID {
+		path := findCodexRollout(agentPID, now)
+		if path == "" {
+			delete(paneRollout, paneID)
+			return nil
+		}
+		t = &rolloutTail{jsonlTail: jsonlTail{path: path}, pid: agentPID}
+		paneRollout[paneID] = t
+	}
+	lines, restarted, err := t.read()
+	if err != nil {
+		delete(paneRollout, paneID)
+		return nil
+	}
+	if restarted {
+		t.state = rolloutState{}
+	}
+	for _, line := range lines {
+		t.state.apply(line)
+	}
+	if t.state.phase == "" {
+		return nil
+	}
+	return &t.state
+}
+
+// apply advances the state with one rollout line.
+func (s *rolloutState) apply(line []byte) {
+	var r rolloutRecord
+	if json.Unmarshal(line, &r) != nil {
+		return
+	}
+	var p rolloutPayload
+	if json.Unmarshal(r.Payload, &p) != nil {
+		return
+	}
+	if at, err := time.Parse(time.RFC3339Nano, r.Timestamp); err == nil {
+		s.at = at
+	}
+
+	switch r.Type + "/" + p.Type {
+	case "event_msg/task_started", "event_msg/user_message":
+		s.phase = phaseWorking
+	case "event_msg/task_complete":
+		s.phase, s.lastTurn = phaseWaiting, r.Timestamp
+		s.execs = nil
+	case "event_msg/turn_aborted":
+		s.phase, s.lastTurn = phaseInterrupted, r.Timestamp
+		s.execs = nil
+	case "event_msg/exec_command_begin":
+		s.phase = phaseWorking
+		s.execs = append(s.execs, rolloutExec{callID: p.CallID, command: rolloutCommand(p.Command)})
+	case "event_msg/exec_command_end", "response_item/function_call_output":
+		s.execs = slices.DeleteFunc(s.execs, func(e rolloutExec) bool { return e.callID == p.CallID })
+	case "response_item/function_call":
+		// The model asked for a tool. Codex may still ask the user to
+		// approve it, so nothing runs until exec_command_begin.
+		s.phase = phaseWorking
+	default:
+		return
+	}
+
+	s.command = ""
+	if n := len(s.execs); n > 0 {
+		s.command = s.execs[n-1].command
+	}
+}
+
+// rolloutCommand flattens ["bash", "-lc", "go test ./..."] or a string.
+func rolloutCommand(raw json.RawMessage) string {
+	var argv []string
+	if json.Unmarshal(raw, &argv) == nil {
+		return strings.Join(argv, " ")
+	}
+	var cmd string
+	json.Unmarshal(raw, &cmd)
+	return cmd
+}
+
+// applyRollout replaces the scraped status of a Codex pane with what the
+// rollout says. It returns a completion signature once a turn ends.
+// prompt is set when the pane shows an approval prompt, which the
+// rollout doesn't record.
+func applyRollout(status string, s *rolloutState, now time.Time, prompt bool) (string, string) {
+	if s == nil || !strings.HasPrefix(status, "x ") {
+		return status, ""
+	}
+	icon := strings.TrimPrefix(status, "x ")
+	if s.phase == "" || icon == "🗜️" || strings.Contains(icon, "🚪") {
+		return status, ""
+	}
+	switch s.phase {
+	case phaseWaiting:
+		return "x 💤", "rollout:" + s.lastTurn
+	case phaseInterrupted:
+		return "x ✋", "rollout:" + s.lastTurn
+	}
+	if now.Sub(s.at) > rolloutWorkingMaxAge || icon == "💤" && prompt {
+		return status, ""
+	}
+	if s.command != "" {
+		return "x " + classifyChildren([]string{strings.ToLower(s.command)}), ""
+	}
+	if icon != "💤" && icon != "✋" {
+		return status, "" // child processes already say what is running
+	}
+	return "x 🧠", ""
+}
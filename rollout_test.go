@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRolloutState_Fixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "codex",
+		"rollout-2025-10-10T09-00-00-0199cf4e-2b1a-7c30-9d3e-5f1b2a6c7d8e.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	// Status after replaying the first n lines.
+	statusAfter := func(n int, prompt bool) string {
+		var s rolloutState
+		for _, line := range lines[:n] {
+			s.apply([]byte(line))
+		}
+		status, _ := applyRollout("x 💤", &s, s.at.Add(time.Second), prompt)
+		return status
+	}
+
+	tests := []struct {
+		lines  int
+		prompt bool
+		want   string
+	}{
+		{1, false, "x 💤"}, // session_meta only: no state, pane decides
+		{4, false, "x 🧠"}, // task_started
+		{5, false, "x 🧠"}, // go test requested, not yet running
+		{5, true, "x 💤"},  // ... and waiting for approval
+		{6, false, "x 🧪"}, // exec go test running
+		{8, false, "x 🧠"}, // exec finished, model thinking
+		{10, false, "x 💤"},
+	}
+	for _, tt := range tests {
+		if got := statusAfter(tt.lines, tt.prompt); got != tt.want {
+			t.Errorf("after %d lines (prompt %v): status = %q, want %q", tt.lines, tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRollout(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		status  string
+		state   rolloutState
+		want    string
+		wantSig bool
+	}{
+		{"complete beats stale spinner", "x 🧠", rolloutState{phase: phaseWaiting, lastTurn: "t1"}, "x 💤", true},
+		{"aborted", "x 🧠", rolloutState{phase: phaseInterrupted, lastTurn: "t1"}, "x ✋", true},
+		{"exec icon", "x 💤", rolloutState{phase: phaseWorking, command: "bash -lc cargo build", at: now}, "x 🔨", false},
+		{"working keeps child icon", "x 📦", rolloutState{phase: phaseWorking, at: now}, "x 📦", false},
+		{"old working rollout ignored", "x 💤", rolloutState{phase: phaseWorking, at: now.Add(-time.Hour)}, "x 💤", false},
+		{"exit kept", "x 🧠🚪", rolloutState{phase: phaseWaiting}, "x 🧠🚪", false},
+		{"claude untouched", "c 🧠", rolloutState{phase: phaseWaiting}, "c 🧠", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			got, sig := applyRollout(tt.status, &state, now, false)
+			if got != tt.want || (sig != "") != tt.wantSig {
+				t.Errorf("applyRollout() = (%q, %q), want %q (sig=%v)", got, sig, tt.want, tt.wantSig)
+			}
+		})
+	}
+}
+
+func writeRollout(t *testing.T, root string, start time.Time, cwd, id string) string {
+	t.Helper()
+	dir := filepath.Join(root, start.Format("2006"), start.Format("01"), start.Format("02"))
+	os.MkdirAll(dir, 0755)
+	path := filepath.Join(dir, "rollout-"+start.Format("2006-01-02T15-04-05")+"-"+id+".jsonl")
+	meta := fmt.Sprintf(`{"timestamp":%q,"type":"session_meta","payload":{"id":%q,"timestamp":%q,"cwd":%q}}`+"\n",
+		start.UTC().Format(time.RFC3339Nano), id, start.UTC().Format(time.RFC3339Nano), cwd)
+	os.WriteFile(path, []byte(meta), 0644)
+	return path
+}
+
+func TestFindCodexRollout(t *testing.T) {
+	root := t.TempDir()
+	orig := codexSessionsDir
+	defer func() { codexSessionsDir = orig }()
+	codexSessionsDir = func() string { return root }
+
+	// Match against this test process: its cwd and start time.
+	pid := os.Getpid()
+	cwd := readCwd(pid)
+	started := procStartTime(pid)
+
+	mine := writeRollout(t, root, started.Add(time.Second), cwd, "mine")
+	writeRollout(t, root, started.Add(2*time.Second), "/elsewhere", "other-cwd")
+	writeRollout(t, root, started.Add(-48*time.Hour), cwd, "old-session")
+	writeRollout(t, root, started.Add(5*time.Minute), cwd, "later-session")
+
+	if got := findCodexRollout(pid, time.Now()); got != mine {
+		t.Errorf("findCodexRollout() = %q, want %q", got, mine)
+	}
+}
+
+func TestRolloutCommand(t *testing.T) {
+	if got := rolloutCommand([]byte(`["bash","-lc","npm test"]`)); got != "bash -lc npm test" {
+		t.Errorf("argv form: %q", got)
+	}
+	if got := rolloutCommand([]byte(`"make"`)); got != "make" {
+		t.Errorf("string form: %q", got)
+	}
+}
+
+func TestRolloutState_LatestExec(t *testing.T) {
+	var s rolloutState
+	for _, line := range []string{
+		`{"timestamp":"2025-10-10T09:00:01Z","type":"event_msg","payload":{"type":"exec_command_begin","call_id":"a","command":["go","build","./..."]}}`,
+		`{"timestamp":"2025-10-10T09:00:02Z","type":"event_msg","payload":{"type":"exec_command_begin","call_id":"b","command":["go","test","./..."]}}`,
+	} {
+		s.apply([]byte(line))
+	}
+	if s.command != "go test ./..." {
+		t.Fatalf("command = %q, want the latest exec", s.command)
+	}
+	s.apply([]byte(`{"timestamp":"2025-10-10T09:00:03Z","type":"event_msg","payload":{"type":"exec_command_end","call_id":"b"}}`))
+	if s.command != "go build ./..." {
+		t.Errorf("after the latest ends, command = %q, want the earlier one", s.command)
+	}
+}
+
+func TestPruneRolloutMetaCache(t *testing.T) {
+	defer func() {
+		rolloutMetaCache = make(map[string]rolloutMeta)
+		rolloutCandidates = make(map[string]bool)
+	}()
+	path := writeRollout(t, t.TempDir(), time.Now(), "/src", "kept")
+	rolloutMetaCache["/gone.jsonl"] = rolloutMeta{}
+	if _, ok := readRolloutMeta(path); !ok {
+		t.Fatal("readRolloutMeta failed")
+	}
+	pruneRolloutMetaCache()
+	if _, ok := rolloutMetaCache[path]; !ok {
+		t.Error("this cycle's candidate should stay cached")
+	}
+	if _, ok := rolloutMetaCache["/gone.jsonl"]; ok {
+		t.Error("stale entry should be pruned")
+	}
+	pruneRolloutMetaCache()
+	if len(rolloutMetaCache) != 0 {
+		t.Error("entries not read in a cycle should be pruned")
+	}
+}
+
+func TestPollRollout_PanesInOneWindow(t *testing.T) {
+	root := t.TempDir()
+	orig := codexSessionsDir
+	defer func() { codexSessionsDir = orig }()
+	codexSessionsDir = func() string { return root }
+	defer delete(paneRollout, "%31")
+	defer delete(paneRollout, "%32")
+
+	// Two agents: this test process and its parent.
+	self, parent := os.Getpid(), os.Getppid()
+	for _, pid := range []int{self, parent} {
+		path := writeRollout(t, root, procStartTime(pid), readCwd(pid), fmt.Sprint("pid-", pid))
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		f.WriteString(`{"timestamp":"2025-10-10T09:00:01Z","type":"event_msg","payload":{"type":"task_started"}}` + "\n")
+		f.Close()
+	}
+
+	now := time.Now()
+	var first *rolloutTail
+	for range 2 {
+		if pollRollout("%31", self, now) == nil || pollRollout("%32", parent, now) == nil {
+			t.Fatal("expected rollout state for both panes")
+		}
+		if first == nil {
+			first = paneRollout["%31"]
+		}
+	}
+	if paneRollout["%31"] != first {
+		t.Error("polling the other pane made this one look up its rollout again")
+	}
+}
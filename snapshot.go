@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// `tmux-ai-status status --json` prints every agent pane. It asks the
+// running daemon when there is one, since only the daemon has unread
+// history; otherwise it runs a single detection pass without renaming
+// any windows.
+
+var (
+	// paneReports is the daemon's view of each agent pane as of the last
+	// cycle, in list-panes order.
+	paneReports []*paneReport
+	// paneStatusSince remembers when each pane's raw status last changed.
+	paneStatusSince = make(map[string]paneSince)
+	// dryRun makes setWindowStatus skip tmux, for one-shot passes.
+	dryRun bool
+)
+
+type paneSince struct {
+	status string
+	since  time.Time
+}
+
+// paneReport is what `status --json` and the list-panes method return.
+type paneReport struct {
+	Session         string     `json:"session"`
+	Window          string     `json:"window"`
+	PaneID          string     `json:"pane_id"`
+	PID             int        `json:"pid"`
+	Agent           string     `json:"agent"`
+	RawStatus       string     `json:"raw_status"`       // e.g. "c 🧠"
+	EffectiveStatus string     `json:"effective_status"` // window name as rendered
+	Unread          bool       `json:"unread"`
+	Focused         bool       `json:"focused"`
+	Task            string     `json:"task,omitempty"`          // e.g. "🧪"
+	TaskCategory    string     `json:"task_category,omitempty"` // e.g. "test"
+	Cwd             string     `json:"cwd,omitempty"`
+	Title           string     `json:"title,omitempty"`      // pane title set by the agent
+	Command         string     `json:"command,omitempty"`    // pane_current_command
+	StartedAt       *time.Time `json:"started_at,omitempty"` // agent process start
+	StatusSince     time.Time  `json:"status_since"`         // raw status last changed
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func newPaneReport(p paneInfo, agent agentProc, rawStatus string, task childTask, now time.Time) *paneReport {
+	r := &paneReport{
+		Session:      windowSession(p.window),
+		Window:       p.window,
+		PaneID:       p.paneID,
+		PID:          agent.pid,
+		Agent:        agent.name,
+		RawStatus:    rawStatus,
+		Focused:      p.focused,
+		Task:         task.icon,
+		TaskCategory: taskCategory(task.icon),
+		Cwd:          readCwd(agent.pid),
+		Title:        p.title,
+		Command:      p.command,
+		UpdatedAt:    now,
+	}
+	if r.Cwd == "" {
+		r.Cwd = p.path
+	}
+	if started := procStartTime(agent.pid); !started.IsZero() {
+		r.StartedAt = &started
+	}
+	since, ok := paneStatusSince[p.paneID]
+	if !ok || since.status != rawStatus {
+		since = paneSince{status: rawStatus, since: now}
+		paneStatusSince[p.paneID] = since
+	}
+	r.StatusSince = since.since
+	return r
+}
+
+func runStatus(args []string) int {
+	asJSON := false
+	for _, a := range args {
+		switch a {
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintf(os.Stderr, "usage: tmux-ai-status status [--json]\n")
+			return 2
+		}
+	}
+
+	panes, err := snapshotPanes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status status: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		out, _ := json.MarshalIndent(panes, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	}
+	for _, p := range panes {
+		unread := ""
+		if p.Unread {
+			unread = " (unread)"
+		}
+		fmt.Printf("%s\t%s\t%s\t%s%s\n", p.Window, p.PaneID, p.Agent, p.RawStatus, unread)
+	}
+	return 0
+}
+
+// snapshotPanes asks the daemon for its pane reports, falling back to a
+// dry detection pass of our own.
+func snapshotPanes() ([]*paneReport, error) {
+	if resp, err := callAPI(apiSocketPath(), apiRequest{Method: "list-panes"}); err == nil {
+		if !resp.OK {
+			return nil, fmt.Errorf("%s", resp.Error)
+		}
+		var panes []*paneReport
+		data, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(data, &panes); err != nil {
+			return nil, err
+		}
+		return panes, nil
+	}
+
+	c, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status status: %v (using defaults)\n", err)
+	}
+	cfg = c
+	dryRun = true
+	updateAllPanes()
+	if paneReports == nil {
+		return []*paneReport{}, nil
+	}
+	return paneReports, nil
+}
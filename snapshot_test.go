@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewPaneReport(t *testing.T) {
+	defer delete(paneStatusSince, "%42")
+	pid := os.Getpid()
+	p := paneInfo{window: "dev:3", pid: pid, focused: true, paneID: "%42"}
+	agent := agentProc{pid: pid, name: "claude", paneID: "%42"}
+	t0 := time.Now()
+
+	r := newPaneReport(p, agent, "c 🧪", childTask{icon: "🧪"}, t0)
+	wd, _ := os.Getwd()
+	if r.Session != "dev" || r.Window != "dev:3" || r.PID != pid || r.Agent != "claude" || !r.Focused {
+		t.Errorf("identity fields = %+v", r)
+	}
+	if r.TaskCategory != "test" || r.Cwd != wd || r.StartedAt == nil || !r.StatusSince.Equal(t0) {
+		t.Errorf("detail fields = %+v", r)
+	}
+
+	r = newPaneReport(p, agent, "c 🧪", childTask{icon: "🧪"}, t0.Add(4*time.Second))
+	if !r.StatusSince.Equal(t0) {
+		t.Errorf("status_since moved without a status change: %v", r.StatusSince)
+	}
+	t1 := t0.Add(8 * time.Second)
+	r = newPaneReport(p, agent, "c 💤", childTask{}, t1)
+	if !r.StatusSince.Equal(t1) || r.Task != "" || r.TaskCategory != "" {
+		t.Errorf("after status change = %+v", r)
+	}
+}
+
+func TestTaskCategory(t *testing.T) {
+	for icon, want := range map[string]string{
+		"🔨": "build", "🧪": "test", "📦": "install", "🔀": "git", "🌐": "network", "⚙️": "command", "": "",
+	} {
+		if got := taskCategory(icon); got != want {
+			t.Errorf("taskCategory(%q) = %q, want %q", icon, got, want)
+		}
+	}
+}
+
+func TestHandleRequest_ListPanes(t *testing.T) {
+	orig := paneReports
+	defer func() { paneReports = orig }()
+
+	paneReports = nil
+	resp := handleRequest(apiRequest{Method: "list-panes"}, time.Now())
+	if list, ok := resp.Result.([]*paneReport); !resp.OK || !ok || list == nil {
+		t.Errorf("empty list-panes should be an empty array, got %+v", resp)
+	}
+
+	paneReports = []*paneReport{{Window: "dev:1", PaneID: "%1"}, {Window: "dev:1", PaneID: "%2"}}
+	resp = handleRequest(apiRequest{Method: "list-panes"}, time.Now())
+	if list, _ := resp.Result.([]*paneReport); len(list) != 2 || list[1].PaneID != "%2" {
+		t.Errorf("list-panes = %+v", resp)
+	}
+}
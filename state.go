@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// The daemon and the short-lived subcommands invoked by agents (hooks,
+// notify programs) share state through small JSON files, one per pane
+// and kind, under stateDir().
+
+// stateDir is $XDG_RUNTIME_DIR/tmux-ai-status, falling back to a per-user
+// directory in the system temp dir. Anyone can create that one first, so
+// it is only used once checkPrivateDir accepts it.
+func stateDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "tmux-ai-status")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("tmux-ai-status-%d", os.Getuid()))
+}
+
+// ensureStateDir creates stateDir() if needed and checks it.
+func ensureStateDir() error {
+	if err := os.Mkdir(stateDir(), 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return checkPrivateDir(stateDir())
+}
+
+// checkPrivateDir refuses dir unless it is a real directory (not a
+// symlink) owned by the current user and closed to everyone else, so
+// that no other user can plant state files or a socket in it.
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || !ok || int(st.Uid) != os.Getuid() || info.Mode().Perm() != 0700 {
+		return fmt.Errorf("%s is not a private directory owned by uid %d (mode 0700); remove it", dir, os.Getuid())
+	}
+	return nil
+}
+
+// paneStatePath returns the file holding one kind of state for a tmux
+// pane id such as "%12".
+func paneStatePath(paneID, kind string) string {
+	id := strings.TrimPrefix(paneID, "%")
+	return filepath.Join(stateDir(), "panes", id+"."+kind+".json")
+}
+
+// prunePaneState removes the state files of panes not in live. Files
+// written since listedAt are kept: their pane may be newer than the
+// list.
+func prunePaneState(live map[string]bool, listedAt time.Time) {
+	if checkPrivateDir(stateDir()) != nil {
+		return
+	}
+	dir := filepath.Join(stateDir(), "panes")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		id, _, ok := strings.Cut(e.Name(), ".")
+		if !ok || id == "" || live["%"+id] {
+			continue
+		}
+		if info, err := e.Info(); err != nil || !info.ModTime().Before(listedAt) {
+			continue
+		}
+		os.Remove(filepath.Join(dir, e.Name()))
+	}
+}
+
+// writeState atomically replaces path with the JSON encoding of v.
+func writeState(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := ensureStateDir(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readState decodes path into v. A missing file is not an error;
+// ok reports whether anything was read.
+func readState(path string, v any) (ok bool, err error) {
+	if err := checkPrivateDir(stateDir()); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return true, nil
+}
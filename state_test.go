@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// privateTempDir is a t.TempDir() that checkPrivateDir accepts.
+func privateTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCheckPrivateDir(t *testing.T) {
+	root := t.TempDir()
+	private := filepath.Join(root, "private")
+	open := filepath.Join(root, "open")
+	link := filepath.Join(root, "link")
+	os.Mkdir(private, 0700)
+	os.Mkdir(open, 0700)
+	os.Chmod(open, 0777)
+	os.Symlink(private, link)
+
+	if err := checkPrivateDir(private); err != nil {
+		t.Errorf("private dir refused: %v", err)
+	}
+	for _, dir := range []string{open, link, filepath.Join(root, "missing")} {
+		if checkPrivateDir(dir) == nil {
+			t.Errorf("%s should be refused", filepath.Base(dir))
+		}
+	}
+}
+
+func TestWriteState_RefusesForeignDir(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	os.Mkdir(stateDir(), 0700)
+	os.Chmod(stateDir(), 0777) // as if someone else had made it
+
+	if err := writeState(paneStatePath("%1", "hook"), hookEvent{}); err == nil {
+		t.Error("writeState should refuse a directory others can write to")
+	}
+	var ev hookEvent
+	if _, err := readState(paneStatePath("%1", "hook"), &ev); err == nil {
+		t.Error("readState should refuse a directory others can write to")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Claude Code statusLine provider: with
+//
+//	"statusLine": {"type": "command", "command": "tmux-ai-status statusline"}
+//
+// in ~/.claude/settings.json, Claude pipes session JSON to us on every
+// render. We print cfg.StatuslineFormat for Claude and record model, cost
+// and transcript path for the calling pane, so tabs can show them and
+// the transcript reader does not have to guess the session file.
+
+// statuslineInput is the subset of Claude's statusLine payload we use.
+type statuslineInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	Version        string `json:"version"`
+	Model          struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+	} `json:"model"`
+	Workspace struct {
+		CurrentDir string `json:"current_dir"`
+	} `json:"workspace"`
+	Cost struct {
+		TotalCostUSD float64 `json:"total_cost_usd"`
+	} `json:"cost"`
+}
+
+// sessionInfo is what `statusline` records for a pane.
+type sessionInfo struct {
+	SessionID      string    `json:"session_id"`
+	Model          string    `json:"model"`
+	ModelID        string    `json:"model_id"`
+	CostUSD        float64   `json:"cost_usd"`
+	TranscriptPath string    `json:"transcript_path"`
+	At             time.Time `json:"at"`
+}
+
+func runStatusline(args []string) int {
+	c, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status statusline: %v\n", err)
+	}
+	line, err := statusline(os.Stdin, os.Getenv("TMUX_PANE"), c.StatuslineFormat, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status statusline: %v\n", err)
+		return 1
+	}
+	fmt.Println(line)
+	return 0
+}
+
+// statusline records the session for paneID (if any) and renders format.
+func statusline(r io.Reader, paneID, format string, now time.Time) (string, error) {
+	var in statuslineInput
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return "", fmt.Errorf("decode payload: %w", err)
+	}
+	if paneID != "" {
+		err := writeState(paneStatePath(paneID, "session"), sessionInfo{
+			SessionID:      in.SessionID,
+			Model:          in.Model.DisplayName,
+			ModelID:        in.Model.ID,
+			CostUSD:        in.Cost.TotalCostUSD,
+			TranscriptPath: in.TranscriptPath,
+			At:             now,
+		})
+		if err != nil {
+			// The status line itself matters more than our bookkeeping.
+			fmt.Fprintf(os.Stderr, "tmux-ai-status statusline: %v\n", err)
+		}
+	}
+
+	dir := in.Workspace.CurrentDir
+	if dir == "" {
+		dir = in.Cwd
+	}
+	return strings.NewReplacer(
+		"{model}", in.Model.DisplayName,
+		"{cost}", formatCost(in.Cost.TotalCostUSD),
+		"{dir}", filepath.Base(dir),
+		"{cwd}", dir,
+		"{version}", in.Version,
+	).Replace(format), nil
+}
+
+// readSessionInfo returns the statusLine report for a pane if it was
+// written by the current agent process.
+func readSessionInfo(paneID string, agentStarted time.Time) *sessionInfo {
+	if paneID == "" {
+		return nil
+	}
+	var info sessionInfo
+	if ok, err := readState(paneStatePath(paneID, "session"), &info); !ok || err != nil {
+		return nil
+	}
+	if info.At.Before(agentStarted) {
+		return nil // left behind by an earlier agent in this pane
+	}
+	return &info
+}
+
+func formatCost(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const statuslinePayload = `{
+  "hook_event_name": "Status",
+  "session_id": "5d1c",
+  "transcript_path": "/home/dev/.claude/projects/-home-dev-app/5d1c.jsonl",
+  "cwd": "/home/dev/app",
+  "model": {"id": "claude-opus-4-1", "display_name": "Opus"},
+  "workspace": {"current_dir": "/home/dev/app/web", "project_dir": "/home/dev/app"},
+  "version": "1.0.80",
+  "cost": {"total_cost_usd": 1.234, "total_duration_ms": 45000}
+}`
+
+func TestStatusline(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	now := time.Now()
+
+	line, err := statusline(strings.NewReader(statuslinePayload), "%9", "{model} · {cost} · {dir}", now)
+	if err != nil {
+		t.Fatalf("statusline: %v", err)
+	}
+	if line != "Opus · $1.23 · web" {
+		t.Errorf("line = %q", line)
+	}
+
+	info := readSessionInfo("%9", now.Add(-time.Minute))
+	if info == nil {
+		t.Fatal("expected recorded session")
+	}
+	if info.Model != "Opus" || info.CostUSD != 1.234 || info.SessionID != "5d1c" ||
+		info.TranscriptPath != "/home/dev/.claude/projects/-home-dev-app/5d1c.jsonl" {
+		t.Errorf("unexpected session info: %+v", info)
+	}
+	if readSessionInfo("%9", now.Add(time.Minute)) != nil {
+		t.Error("a report older than the agent should be ignored")
+	}
+}
+
+func TestStatusline_OutsideTmux(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	line, err := statusline(strings.NewReader(statuslinePayload), "", "{cwd}", time.Now())
+	if err != nil || line != "/home/dev/app/web" {
+		t.Errorf("statusline() = %q, %v", line, err)
+	}
+	if _, err := statusline(strings.NewReader("nope"), "", "{model}", time.Now()); err == nil {
+		t.Error("expected decode error")
+	}
+}
+
+func TestRenderWindowName_ModelAndCost(t *testing.T) {
+	orig := cfg
+	defer func() { cfg = orig }()
+	cfg.Format = "{status}{model}{cost}"
+
+	footer := noFooter
+	footer.model, footer.costUSD = "Opus", 0.5
+	if got := renderWindowName("c 🧠", footer); got != "c 🧠 Opus $0.50" {
+		t.Errorf("got %q", got)
+	}
+	if got := renderWindowName("c 🧠", noFooter); got != "c 🧠" {
+		t.Errorf("without a statusline report: got %q", got)
+	}
+}
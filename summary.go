@@ -0,0 +1,140 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Summary options: after every cycle the daemon counts agent windows by
+// state and publishes the result as tmux user options, @ai_summary for
+// all sessions and @ai_session_summary per session, for use in
+// status-right or choose-tree formats. Options are only written when
+// their text changes; the first cycle also unsets the session option a
+// previous daemon may have left on sessions without agents.
+
+const (
+	summaryOption        = "@ai_summary"
+	sessionSummaryOption = "@ai_session_summary"
+)
+
+type summaryCounts struct {
+	total, working, unread, permission, idle, interrupted, exited int
+}
+
+func (c *summaryCounts) add(r *windowReport, class string) {
+	c.total++
+	switch {
+	case class == classPermission:
+		c.permission++
+	case r.Unread:
+		c.unread++
+	case class == classWorking:
+		c.working++
+	case class == classInterrupted:
+		c.interrupted++
+	case class == classExited:
+		c.exited++
+	default:
+		c.idle++
+	}
+}
+
+// countWindows tallies reports globally and per session.
+func countWindows(reports map[string]*windowReport, classes map[string]classState) (summaryCounts, map[string]*summaryCounts) {
+	var all summaryCounts
+	sessions := make(map[string]*summaryCounts)
+	for window, r := range reports {
+		class := classes[window].class
+		all.add(r, class)
+		c := sessions[r.Session]
+		if c == nil {
+			c = &summaryCounts{}
+			sessions[r.Session] = c
+		}
+		c.add(r, class)
+	}
+	return all, sessions
+}
+
+// renderSummary fills format; a state placeholder is its icon and count,
+// or nothing when the count is zero, and runs of spaces collapse.
+func renderSummary(format string, c summaryCounts) string {
+	n := func(icon string, v int) string {
+		if v == 0 {
+			return ""
+		}
+		return icon + strconv.Itoa(v)
+	}
+	s := strings.NewReplacer(
+		"{total}", strconv.Itoa(c.total),
+		"{working}", n("🧠", c.working),
+		"{unread}", n("📬", c.unread),
+		"{permission}", n("🔐", c.permission),
+		"{idle}", n("💤", c.idle),
+		"{interrupted}", n("✋", c.interrupted),
+		"{exited}", n("🚪", c.exited),
+	).Replace(format)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var (
+	// appliedSummary is the text last written per session; "" keys the
+	// global option.
+	appliedSummary = make(map[string]string)
+	// summaryCleared is set once the first cycle has cleared leftovers.
+	summaryCleared bool
+)
+
+// summaryArgs is the tmux command that sets the summary of session, or
+// the global one when session is "". An empty session summary is unset.
+func summaryArgs(session, text string) []string {
+	if session == "" {
+		return []string{"set-option", "-g", summaryOption, text}
+	}
+	if text == "" {
+		return []string{"set-option", "-u", "-t", "=" + session + ":", sessionSummaryOption}
+	}
+	return []string{"set-option", "-t", "=" + session + ":", sessionSummaryOption, text}
+}
+
+// updateSummaries writes the options whose text changed. sessions lists
+// every session seen this cycle, agents or not.
+func updateSummaries(reports map[string]*windowReport, classes map[string]classState, sessions map[string]bool) {
+	all, counts := countWindows(reports, classes)
+	want := map[string]string{"": renderSummary(cfg.SummaryFormat, all)}
+	for session := range sessions {
+		text := ""
+		if c := counts[session]; c != nil {
+			text = renderSummary(cfg.SummaryFormat, *c)
+		}
+		want[session] = text
+	}
+	keys := make([]string, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		text := want[key]
+		prev, ok := appliedSummary[key]
+		if ok && prev == text || !ok && key != "" && text == "" && summaryCleared {
+			continue
+		}
+		appliedSummary[key] = text
+		args := summaryArgs(key, text)
+		if logDryRun {
+			log.Printf("dry-run: would run %s (summary)", shellQuote(args))
+		}
+		if !dryRun {
+			runTmux(args...)
+		}
+	}
+	for key := range appliedSummary {
+		if _, ok := want[key]; !ok {
+			delete(appliedSummary, key)
+		}
+	}
+	summaryCleared = true
+}
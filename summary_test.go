@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderSummary(t *testing.T) {
+	c := summaryCounts{total: 7, working: 4, unread: 2, permission: 1}
+	tests := []struct {
+		format, want string
+	}{
+		{"{working} {unread} {permission}", "🧠4 📬2 🔐1"},
+		{"{idle} {working}  {exited} {unread}", "🧠4 📬2"},
+		{"AI {total}: {working}", "AI 7: 🧠4"},
+		{"{idle}", ""},
+	}
+	for _, tt := range tests {
+		if got := renderSummary(tt.format, c); got != tt.want {
+			t.Errorf("renderSummary(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestCountWindows(t *testing.T) {
+	reports := map[string]*windowReport{
+		"a:1": {Session: "a"},
+		"a:2": {Session: "a", Unread: true},
+		"a:3": {Session: "a", Unread: true},
+		"b:1": {Session: "b"},
+		"b:2": {Session: "b"},
+	}
+	classes := map[string]classState{
+		"a:1": {class: classWorking},
+		"a:2": {class: classIdle},
+		"a:3": {class: classPermission},
+		"b:1": {class: classExited},
+		"b:2": {class: classIdle},
+	}
+	all, sessions := countWindows(reports, classes)
+	if want := (summaryCounts{total: 5, working: 1, unread: 1, permission: 1, exited: 1, idle: 1}); all != want {
+		t.Errorf("all = %+v, want %+v", all, want)
+	}
+	if want := (summaryCounts{total: 2, exited: 1, idle: 1}); *sessions["b"] != want {
+		t.Errorf("b = %+v, want %+v", *sessions["b"], want)
+	}
+}
+
+func TestUpdateSummaries(t *testing.T) {
+	origRun, origApplied, origCleared, origCfg := runTmux, appliedSummary, summaryCleared, cfg
+	defer func() { runTmux, appliedSummary, summaryCleared, cfg = origRun, origApplied, origCleared, origCfg }()
+	appliedSummary, summaryCleared = make(map[string]string), false
+	cfg = defaultConfig()
+	var ran []string
+	runTmux = func(args ...string) error {
+		ran = append(ran, strings.Join(args, " "))
+		return nil
+	}
+
+	reports := map[string]*windowReport{"a:1": {Session: "a"}}
+	classes := map[string]classState{"a:1": {class: classWorking}}
+	sessions := map[string]bool{"a": true, "b": true}
+	updateSummaries(reports, classes, sessions)
+	want := []string{
+		"set-option -g @ai_summary 🧠1",
+		"set-option -t =a: @ai_session_summary 🧠1",
+		"set-option -u -t =b: @ai_session_summary", // left by a previous daemon
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("first cycle ran %q, want %q", ran, want)
+	}
+
+	ran = nil
+	sessions["c"] = true // new session without agents
+	updateSummaries(reports, classes, sessions)
+	if len(ran) != 0 {
+		t.Errorf("unchanged summaries should not be rewritten, ran %q", ran)
+	}
+
+	classes["a:1"] = classState{class: classIdle}
+	reports["a:1"].Unread = true
+	delete(sessions, "b")
+	delete(sessions, "c")
+	updateSummaries(reports, classes, sessions)
+	want = []string{
+		"set-option -g @ai_summary 📬1",
+		"set-option -t =a: @ai_session_summary 📬1",
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("change ran %q, want %q", ran, want)
+	}
+
+	ran = nil
+	updateSummaries(map[string]*windowReport{}, classes, sessions)
+	want = []string{
+		"set-option -g @ai_summary ",
+		"set-option -u -t =a: @ai_session_summary",
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("last agent gone ran %q, want %q", ran, want)
+	}
+}
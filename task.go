@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// Task outcome tracking: classifyChildren only knows a test or build is
+// running while the process exists. Follow the task per pane so that
+// when it ends we can briefly show whether it passed or failed.
+var (
+	paneTask   = make(map[string]childTask)
+	paneResult = make(map[string]*taskResult)
+	// paneTaskScreen is the pane as the current run started, so that
+	// summaries of earlier runs still on screen are not taken for its.
+	paneTaskScreen = make(map[string]string)
+)
+
+// childTask is the worker subprocess set an agent is running.
+type childTask struct {
+	icon string // classifyChildren result, e.g. "🧪"
+	pids []int
+	// root is the agent's child the matched command runs under, and
+	// started its start time; together they tell two back-to-back runs
+	// apart, while the icon may change during one (go test compiling
+	// shows 🔨, then 🧪).
+	root    int
+	started time.Time
+}
+
+// sameRun reports whether t and o are the same task seen in two cycles.
+func (t childTask) sameRun(o childTask) bool {
+	if t.root == 0 || o.root == 0 {
+		return t.icon == o.icon && t.root == o.root
+	}
+	return t.root == o.root && t.started.Equal(o.started)
+}
+
+type taskResult struct {
+	icon   string // icon of the task that finished
+	passed bool
+	at     time.Time
+}
+
+func (r *taskResult) badge() string {
+	if r.passed {
+		return r.icon + "✅"
+	}
+	return r.icon + "❌"
+}
+
+func isTrackedTask(icon string) bool {
+	return icon == "🧪" || icon == "🔨"
+}
+
+// trackTaskResult updates the task seen in a pane and returns the
+// outcome badge to show, or "" when there is nothing recent to report.
+func trackTaskResult(pane string, task childTask, paneCache map[string]*paneCapture, now time.Time) string {
+	prev, hadTask := paneTask[pane]
+	before := paneTaskScreen[pane]
+	if isTrackedTask(task.icon) {
+		paneTask[pane] = task
+		if !hadTask || !prev.sameRun(task) {
+			// A new run supersedes the previous outcome.
+			delete(paneResult, pane)
+			content, _ := getPaneContent(pane, paneCache)
+			paneTaskScreen[pane] = content
+		}
+	} else {
+		delete(paneTask, pane)
+		delete(paneTaskScreen, pane)
+	}
+
+	if hadTask && !prev.sameRun(task) {
+		if passed, ok := taskOutcome(prev, pane, before, paneCache); ok {
+			paneResult[pane] = &taskResult{icon: prev.icon, passed: passed, at: now}
+		}
+	}
+
+	r, ok := paneResult[pane]
+	if !ok {
+		return ""
+	}
+	if now.Sub(r.at) >= cfg.ResultLinger.Duration {
+		delete(paneResult, pane)
+		return ""
+	}
+	return r.badge()
+}
+
+// taskOutcome prefers an exit status (only readable while the finished
+// process is still an unreaped zombie) and falls back to summary lines
+// printed by the test runner or build tool since the pane showed before.
+func taskOutcome(task childTask, pane, before string, paneCache map[string]*paneCapture) (passed, ok bool) {
+	sawZero := false
+	for _, pid := range task.pids {
+		switch code := readExitCode(pid); {
+		case code > 0:
+			return false, true
+		case code == 0:
+			sawZero = true
+		}
+	}
+	if content, ok := getPaneContent(pane, paneCache); ok {
+		if passed, ok := classifyTaskOutcome(newOutput(before, content)); ok {
+			return passed, true
+		}
+	}
+	return sawZero, sawZero
+}
+
+// newOutput drops the lines of content that were already on screen in
+// before, oldest first, leaving what the run printed. A summary that
+// scrolled away is dropped too, which is safer than a stale one.
+func newOutput(before, content string) string {
+	seen := make(map[string]int)
+	for _, line := range strings.Split(before, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			seen[line]++
+		}
+	}
+	var out []string
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); seen[trimmed] > 0 {
+			seen[trimmed]--
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// classifyTaskOutcome looks for the most recent pass/fail summary in the
+// pane: go test, pytest, jest/vitest, cargo and common build tools.
+func classifyTaskOutcome(content string) (passed, ok bool) {
+	lines := strings.Split(content, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < 40; i-- {
+		// Claude indents tool output behind "⎿".
+		line := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lines[i]), "⎿"))
+		if line == "" {
+			continue
+		}
+		checked++
+		if isFailureSummary(line) {
+			return false, true
+		}
+		if isSuccessSummary(line) {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+func isFailureSummary(line string) bool {
+	lower := strings.ToLower(line)
+	switch {
+	case line == "FAIL", strings.HasPrefix(line, "FAIL\t"), strings.HasPrefix(line, "FAIL "),
+		strings.HasPrefix(line, "--- FAIL"):
+		return true // go test; jest prints "FAIL src/foo.test.ts"
+	case strings.HasPrefix(line, "test result: FAILED"):
+		return true // cargo test
+	case strings.HasPrefix(line, "error: could not compile"):
+		return true // cargo build
+	case strings.HasPrefix(line, "Tests:") && strings.Contains(line, " failed"):
+		return true // jest / vitest
+	case strings.HasPrefix(line, "=") && containsAny(lower, " failed", " error"):
+		return true // pytest "==== 1 failed, 2 passed in 0.12s ===="
+	case strings.Contains(line, "BUILD FAILED"), strings.HasPrefix(line, "make: ***"),
+		strings.HasPrefix(line, "npm ERR!"), strings.HasPrefix(lower, "build failed"):
+		return true
+	}
+	return false
+}
+
+func isSuccessSummary(line string) bool {
+	lower := strings.ToLower(line)
+	switch {
+	case line == "PASS", strings.HasPrefix(line, "ok  \t"), strings.HasPrefix(line, "ok \t"):
+		return true // go test
+	case strings.HasPrefix(line, "test result: ok."):
+		return true // cargo test
+	case strings.HasPrefix(line, "Finished ") && strings.Contains(line, "target"):
+		return true // cargo build "Finished `dev` profile [unoptimized] target(s) in 1.2s"
+	case strings.HasPrefix(line, "Tests:") && strings.Contains(line, " passed"):
+		return true // jest / vitest
+	case strings.HasPrefix(line, "=") && strings.Contains(lower, " passed"):
+		return true // pytest "==== 3 passed in 0.12s ===="
+	case strings.Contains(line, "BUILD SUCCESSFUL"), strings.HasPrefix(lower, "build succeeded"),
+		strings.HasPrefix(lower, "compiled successfully"):
+		return true
+	}
+	return false
+}
+
+// taskCategory names a classifyChildren icon for machine consumers.
+func taskCategory(icon string) string {
+	switch icon {
+	case "🔨":
+		return "build"
+	case "🧪":
+		return "test"
+	case "📦":
+		return "install"
+	case "🔀":
+		return "git"
+	case "🌐":
+		return "network"
+	case "⚙️":
+		return "command"
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyTaskOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		passed  bool
+		ok      bool
+	}{
+		{"go test pass", "$ go test ./...\nok  \tgithub.com/x/y\t0.012s\n❯ \n", true, true},
+		{"go test fail", "--- FAIL: TestFoo (0.00s)\nFAIL\nFAIL\tgithub.com/x/y\t0.01s\n❯ \n", false, true},
+		{"claude indented go test", "● Bash(go test ./...)\n  ⎿  ok  \tgithub.com/x/y\t0.01s\n\n✻ Thinking…\n", true, true},
+		{"pytest pass", "==================== 3 passed in 0.12s ====================\n", true, true},
+		{"pytest fail", "============ 1 failed, 2 passed in 0.31s ============\n", false, true},
+		{"jest pass", "Tests:       4 passed, 4 total\nTime:        1.2 s\n", true, true},
+		{"jest fail", "Tests:       1 failed, 3 passed, 4 total\nTime:        1.2 s\n", false, true},
+		{"cargo test pass", "test result: ok. 5 passed; 0 failed; 0 ignored\n", true, true},
+		{"cargo test fail", "test result: FAILED. 4 passed; 1 failed; 0 ignored\n", false, true},
+		{"cargo build fail", "error: could not compile `foo` (bin \"foo\") due to 2 previous errors\n", false, true},
+		{"cargo build pass", "   Finished `dev` profile [unoptimized + debuginfo] target(s) in 3.2s\n", true, true},
+		{"latest summary wins", "FAIL\tgithub.com/x/y\t0.01s\n$ go test ./...\nok  \tgithub.com/x/y\t0.01s\n", true, true},
+		{"no summary", "some output\n❯ \n", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, ok := classifyTaskOutcome(tt.content)
+			if passed != tt.passed || ok != tt.ok {
+				t.Errorf("classifyTaskOutcome() = (%v, %v), want (%v, %v)", passed, ok, tt.passed, tt.ok)
+			}
+		})
+	}
+}
+
+func forgetTask(t *testing.T, window string) {
+	t.Cleanup(func() {
+		delete(paneTask, window)
+		delete(paneResult, window)
+		delete(paneTaskScreen, window)
+	})
+}
+
+func TestNewOutput(t *testing.T) {
+	before := "ok  \tgithub.com/x/y\t0.01s\n$ go test ./...\n"
+	after := "ok  \tgithub.com/x/y\t0.01s\n$ go test ./...\nFAIL\tgithub.com/x/y\t0.01s\n$ \n"
+	if got := newOutput(before, after); got != "FAIL\tgithub.com/x/y\t0.01s\n$ \n" {
+		t.Errorf("newOutput() = %q", got)
+	}
+	again := before + "ok  \tgithub.com/x/y\t0.01s\n"
+	if got := newOutput(before, again); got != "ok  \tgithub.com/x/y\t0.01s\n" {
+		t.Errorf("a repeated summary should count as new, got %q", got)
+	}
+}
+
+func TestTrackTaskResult(t *testing.T) {
+	window := "test:task"
+	forgetTask(t, window)
+
+	cache := map[string]*paneCapture{window: {content: "$ go test ./...\n", ok: true}}
+	now := time.Now()
+	running := childTask{icon: "🧪", pids: []int{999999999}}
+
+	if got := trackTaskResult(window, running, cache, now); got != "" {
+		t.Fatalf("running task should have no badge, got %q", got)
+	}
+	cache[window] = &paneCapture{content: "$ go test ./...\n--- FAIL: TestFoo\nFAIL\tgithub.com/x/y\t0.01s\n", ok: true}
+	if got := trackTaskResult(window, childTask{}, cache, now.Add(2*time.Second)); got != "🧪❌" {
+		t.Fatalf("finished failing task badge = %q, want 🧪❌", got)
+	}
+	if got := trackTaskResult(window, childTask{}, cache, now.Add(4*time.Second)); got != "🧪❌" {
+		t.Errorf("badge should linger, got %q", got)
+	}
+	if got := trackTaskResult(window, running, cache, now.Add(6*time.Second)); got != "" {
+		t.Errorf("new run should clear the old outcome, got %q", got)
+	}
+	if got := trackTaskResult(window, childTask{}, cache, now.Add(8*time.Second)); got != "" {
+		t.Errorf("the earlier run's summary should not count for this one, got %q", got)
+	}
+	cache[window] = &paneCapture{content: "ok  \tgithub.com/x/y\t0.01s\n", ok: true}
+	trackTaskResult(window, running, cache, now.Add(10*time.Second))
+	if got := trackTaskResult(window, childTask{}, cache, now.Add(12*time.Second+cfg.ResultLinger.Duration)); got != "" {
+		t.Errorf("badge should expire, got %q", got)
+	}
+}
+
+func TestTrackTaskResult_IconChangesDuringRun(t *testing.T) {
+	window := "test:task-flip"
+	forgetTask(t, window)
+
+	cache := map[string]*paneCapture{window: {content: "$ go test ./...\n", ok: true}}
+	now := time.Now()
+	compiling := childTask{icon: "🔨", root: 999999997, started: now}
+	running := childTask{icon: "🧪", root: 999999997, started: now}
+
+	trackTaskResult(window, compiling, cache, now)
+	cache[window] = &paneCapture{content: "$ go test ./...\nok  \tgithub.com/x/y\t0.01s\n", ok: true}
+	if got := trackTaskResult(window, running, cache, now.Add(2*time.Second)); got != "" {
+		t.Errorf("compile then test is one run, got badge %q", got)
+	}
+	if got := trackTaskResult(window, childTask{}, cache, now.Add(4*time.Second)); got != "🧪✅" {
+		t.Errorf("finished run badge = %q, want 🧪✅", got)
+	}
+}
+
+func TestTrackTaskResult_IgnoresOtherTasks(t *testing.T) {
+	window := "test:task-other"
+	forgetTask(t, window)
+
+	cache := map[string]*paneCapture{window: {content: "ok  \tgithub.com/x/y\n", ok: true}}
+	now := time.Now()
+	trackTaskResult(window, childTask{icon: "🔀"}, cache, now)
+	if got := trackTaskResult(window, childTask{}, cache, now.Add(2*time.Second)); got != "" {
+		t.Errorf("git task should not produce an outcome, got %q", got)
+	}
+}
+
+func TestTrackTaskResult_BackToBackRuns(t *testing.T) {
+	window := "test:task-again"
+	forgetTask(t, window)
+
+	cache := map[string]*paneCapture{window: {content: "$ go test ./...\n", ok: true}}
+	now := time.Now()
+	first := childTask{icon: "🧪", pids: []int{999999998}, root: 999999998, started: now}
+	second := childTask{icon: "🧪", pids: []int{999999999}, root: 999999999, started: now.Add(2 * time.Second)}
+
+	trackTaskResult(window, first, cache, now)
+	cache[window] = &paneCapture{content: "$ go test ./...\nok  \tgithub.com/x/y\t0.01s\n", ok: true}
+	if got := trackTaskResult(window, second, cache, now.Add(2*time.Second)); got != "🧪✅" {
+		t.Errorf("a run replaced by another should report its outcome, got %q", got)
+	}
+	if got := trackTaskResult(window, second, cache, now.Add(4*time.Second)); got != "🧪✅" {
+		t.Errorf("outcome should linger while the next run goes on, got %q", got)
+	}
+}
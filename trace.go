@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Decision traces: every cycle records, per window, the steps that led to
+// its status so `tmux-ai-status explain` can show which rule fired. The
+// detection code calls tracef; curTrace points at the window being
+// decided and is nil outside updateAllPanes.
+
+var (
+	curTrace *decisionTrace
+	// windowTraces holds the traces of the last cycle, and tracedPanes
+	// the window of each pane it saw.
+	windowTraces = make(map[string]*decisionTrace)
+	tracedPanes  = make(map[string]string)
+)
+
+type decisionTrace struct {
+	lines []string
+}
+
+func tracef(format string, args ...any) {
+	if curTrace != nil {
+		curTrace.lines = append(curTrace.lines, fmt.Sprintf(format, args...))
+	}
+}
+
+// tracePaneLines records the captured lines that matched a marker.
+func tracePaneLines(pane string, paneCache map[string]*paneCapture) {
+	if curTrace == nil {
+		return
+	}
+	c, ok := paneCache[pane]
+	if !ok {
+		return
+	}
+	if !c.ok {
+		tracef("pane capture failed")
+		return
+	}
+	lines := strings.Split(c.content, "\n")
+	var matched []string
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < 20; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		checked++
+		var tags []string
+		if isCompletionLine(line) {
+			tags = append(tags, "completion")
+		}
+		if isCompactingLine(line) {
+			tags = append(tags, "compacting")
+		} else if hasActiveMarker(line) {
+			tags = append(tags, "active")
+		}
+		if detectPromptSignature(line) != "" {
+			tags = append(tags, "prompt")
+		}
+		if classifyPaneInterrupted(line) {
+			tags = append(tags, "interrupted")
+		}
+		if len(tags) > 0 {
+			matched = append(matched, fmt.Sprintf("  line -%d [%s] %s", checked, strings.Join(tags, ","), line))
+		}
+	}
+	if len(matched) == 0 {
+		tracef("pane text: no marker in the last %d lines", checked)
+		return
+	}
+	tracef("pane text (counting up from the bottom):")
+	for i := len(matched) - 1; i >= 0; i-- {
+		tracef("%s", matched[i])
+	}
+}
+
+// traceTimers records the grace, stale-marker and motion state of a pane.
+func traceTimers(pane string, now time.Time) {
+	if curTrace == nil {
+		return
+	}
+	lastActiveMu.Lock()
+	last, ok := lastActive[pane]
+	lastActiveMu.Unlock()
+	if ok {
+		tracef("active grace: last active %s ago (grace %s)", now.Sub(last).Round(time.Second), activeGrace)
+	} else {
+		tracef("active grace: not running")
+	}
+	if sig, ok := paneActiveSig[pane]; ok {
+		tracef("stale marker: %q held for %s (stale after %s with a prompt visible)",
+			sig, now.Sub(paneActiveAt[pane]).Round(time.Second), staleActiveThreshold)
+	}
+	if fp, ok := paneFingerprint[pane]; ok {
+		tracef("screen motion: changed %d, still %d cycles in a row (need %d/%d)",
+			fp.changed, fp.still, motionCycles, stillCycles)
+	}
+}
+
+func runExplain(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: tmux-ai-status explain TARGET\nTARGET is a window (\"main:2\") or pane id (\"%%7\").\n")
+		return 2
+	}
+	lines, err := explain(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status explain: %v\n", err)
+		return 1
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return 0
+}
+
+// explain asks the daemon for its last trace of target, falling back to a
+// dry detection pass of our own.
+func explain(target string) ([]string, error) {
+	if resp, err := callAPI(apiSocketPath(), apiRequest{Method: "explain", Window: target}); err == nil {
+		if !resp.OK {
+			return nil, fmt.Errorf("%s", resp.Error)
+		}
+		var lines []string
+		data, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(data, &lines); err != nil {
+			return nil, err
+		}
+		return lines, nil
+	}
+
+	c, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tmux-ai-status explain: %v (using defaults)\n", err)
+	}
+	cfg = c
+	dryRun = true
+	updateAllPanes()
+	lines, err := traceFor(target)
+	if err != nil {
+		return nil, err
+	}
+	note := "(no daemon running: one fresh pass, so grace, stale-marker, motion and unread history are empty)"
+	return append([]string{note}, lines...), nil
+}
+
+// traceFor returns the last trace of a window ("session:index") or of the
+// window holding a pane ("%3").
+func traceFor(target string) ([]string, error) {
+	window := target
+	if w, ok := tracedPanes[target]; ok {
+		window = w
+	}
+	t, ok := windowTraces[window]
+	if !ok {
+		return nil, fmt.Errorf("no window %q", target)
+	}
+	return t.lines, nil
+}
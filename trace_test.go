@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func withTrace(t *testing.T) *decisionTrace {
+	t.Helper()
+	tr := &decisionTrace{}
+	curTrace = tr
+	t.Cleanup(func() { curTrace = nil })
+	return tr
+}
+
+func TestTracef_NoTrace(t *testing.T) {
+	curTrace = nil
+	tracef("dropped %d", 1) // must not panic
+}
+
+func TestUnreadDecision_Rule(t *testing.T) {
+	tests := []struct {
+		name                      string
+		wasWorking, focused, work bool
+		seenBefore                bool
+		promptSig, prevPromptSig  string
+		doneSig, prevDoneSig      string
+		want                      string
+	}{
+		{name: "focused", focused: true, want: "focused"},
+		{name: "working", work: true, want: "working"},
+		{name: "completion", wasWorking: true, want: "working -> idle"},
+		{name: "baseline", promptSig: "codex:›", want: "first sight, baseline"},
+		{name: "baseline text", promptSig: "codex:› hi", want: "first sight, prompt has text"},
+		{name: "done", seenBefore: true, doneSig: "Done.", want: "new completion signature"},
+		{name: "prompt", seenBefore: true, promptSig: "claude:❯ y", prevPromptSig: "claude:❯", want: "new prompt signature"},
+		{name: "quiet", seenBefore: true, doneSig: "Done.", prevDoneSig: "Done.", want: "no new signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mark, why := unreadDecision(tt.wasWorking, tt.focused, tt.work, "x 💤", tt.seenBefore,
+				tt.promptSig, tt.prevPromptSig, tt.doneSig, tt.prevDoneSig)
+			if why != tt.want {
+				t.Errorf("rule = %q, want %q", why, tt.want)
+			}
+			if mark != shouldMarkUnread(tt.wasWorking, tt.focused, tt.work, "x 💤", tt.seenBefore,
+				tt.promptSig, tt.prevPromptSig, tt.doneSig, tt.prevDoneSig) {
+				t.Error("unreadDecision and shouldMarkUnread disagree")
+			}
+		})
+	}
+}
+
+func TestMatchChildren(t *testing.T) {
+	if icon, k := matchChildren([]string{"bash -c go test ./..."}); icon != "🧪" || k != "go test" {
+		t.Errorf("go test = %q %q", icon, k)
+	}
+	if icon, k := matchChildren([]string{"sleep 5"}); icon != "⚙️" || k != "" {
+		t.Errorf("sleep = %q %q", icon, k)
+	}
+}
+
+func TestTracePaneLines(t *testing.T) {
+	tr := withTrace(t)
+	cache := map[string]*paneCapture{"tr:1": {ok: true, content: strings.Join([]string{
+		"• Working (12s • esc to interrupt)",
+		"some output",
+		"─ Worked for 1m 02s ─",
+		"› ",
+	}, "\n")}}
+	tracePaneLines("tr:1", cache)
+
+	got := strings.Join(tr.lines, "\n")
+	for _, want := range []string{"[active] • Working", "[completion] ─ Worked for", "[prompt] ›"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trace missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "some output") {
+		t.Errorf("unmatched lines should be left out:\n%s", got)
+	}
+	if strings.Index(got, "Working (12s") > strings.Index(got, "Worked for") {
+		t.Errorf("lines should keep screen order:\n%s", got)
+	}
+}
+
+func TestHandleRequest_Explain(t *testing.T) {
+	origTraces, origPanes := windowTraces, tracedPanes
+	defer func() { windowTraces, tracedPanes = origTraces, origPanes }()
+	windowTraces = map[string]*decisionTrace{"ex:1": {lines: []string{"pane %3", "detected: \"c 🧠\""}}}
+	tracedPanes = map[string]string{"%3": "ex:1"}
+
+	for _, target := range []string{"ex:1", "%3"} {
+		resp := handleRequest(apiRequest{Method: "explain", Window: target}, time.Now())
+		if lines, _ := resp.Result.([]string); !resp.OK || len(lines) != 2 {
+			t.Errorf("explain %s = %+v", target, resp)
+		}
+	}
+	if resp := handleRequest(apiRequest{Method: "explain", Window: "ex:9"}, time.Now()); resp.OK {
+		t.Error("unknown window should fail")
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Claude Code session transcripts: Claude appends every message of a
+// session to ~/.claude/projects/<cwd-slug>/<session-id>.jsonl. Tailing it
+// tells us exactly whether the agent is thinking, running a tool or
+// waiting, which beats guessing from pane text.
+
+// claudeProjectsDir is a var so tests can point it at fixtures.
+var claudeProjectsDir = func() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// paneTranscript follows the transcript of the Claude agent in each pane,
+// by pane id.
+var paneTranscript = make(map[string]*transcriptTail)
+
+// Transcript phases.
+const (
+	phaseWorking     = "working"
+	phaseTool        = "tool"
+	phaseWaiting     = "waiting"
+	phaseInterrupted = "interrupted"
+)
+
+// transcriptWorkingMaxAge is how long a working/tool phase is trusted
+// without new records; a crashed agent leaves its transcript mid-turn.
+const transcriptWorkingMaxAge = 10 * time.Minute
+
+type transcriptState struct {
+	phase       string
+	tool        string    // tool being run in phaseTool
+	lastMessage string    // text of the last assistant message
+	lastID      string    // uuid of the last main-chain record
+	at          time.Time // timestamp of the last main-chain record
+}
+
+type transcriptTail struct {
+	jsonlTail
+	state transcriptState
+}
+
+// transcriptRecord is the subset of a transcript line we use.
+type transcriptRecord struct {
+	Type        string    `json:"type"`
+	UUID        string    `json:"uuid"`
+	IsMeta      bool      `json:"isMeta"`
+	IsSidechain bool      `json:"isSidechain"`
+	Timestamp   time.Time `json:"timestamp"`
+	Message     struct {
+		Content    json.RawMessage `json:"content"`
+		StopReason string          `json:"stop_reason"`
+	} `json:"message"`
+}
+
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Name string `json:"name"`
+}
+
+// claudeProjectSlug mirrors how Claude names project directories: every
+// character that is not a letter or digit becomes "-".
+func claudeProjectSlug(cwd string) string {
+	b := []byte(cwd)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '-'
+		}
+	}
+	return string(b)
+}
+
+// findClaudeTranscript returns the most recently written transcript for
+// the agent's working directory that was touched after the agent started.
+// Two Claude sessions in the same directory are indistinguishable here.
+func findClaudeTranscript(agentPID int) string {
+	cwd := readCwd(agentPID)
+	if cwd == "" {
+		return ""
+	}
+	dir := filepath.Join(claudeProjectsDir(), claudeProjectSlug(cwd))
+	return newestJSONL(dir, procStartTime(agentPID))
+}
+
+func newestJSONL(dir string, notBefore time.Time) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var best string
+	var bestMod time.Time
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().Before(notBefore) {
+			continue
+		}
+		if best == "" || info.ModTime().After(bestMod) {
+			best = filepath.Join(dir, e.Name())
+			bestMod = info.ModTime()
+		}
+	}
+	return best
+}
+
+// pollTranscript follows the transcript at path for a pane and returns
+// its current state, or nil when there is none.
+func pollTranscript(paneID, path string) *transcriptState {
+	if path == "" {
+		delete(paneTranscript, paneID)
+		return nil
+	}
+	t, ok := paneTranscript[paneID]
+	if !ok || t.path != path {
+		t = &transcriptTail{jsonlTail: jsonlTail{path: path}}
+		paneTranscript[paneID] = t
+	}
+	if err := t.poll(); err != nil {
+		delete(paneTranscript, paneID)
+		return nil
+	}
+	if t.state.phase == "" {
+		return nil
+	}
+	return &t.state
+}
+
+func (t *transcriptTail) poll() error {
+	lines, restarted, err := t.read()
+	if restarted {
+		t.state = transcriptState{}
+	}
+	for _, line := range lines {
+		t.state.apply(line)
+	}
+	return err
+}
+
+// apply advances the state with one transcript line.
+func (s *transcriptState) apply(line []byte) {
+	var r transcriptRecord
+	if err := json.Unmarshal(line, &r); err != nil {
+		return
+	}
+	if r.Type != "user" && r.Type != "assistant" {
+		return // attachments, summaries, snapshots, bookkeeping
+	}
+	if r.IsMeta {
+		return
+	}
+	if r.IsSidechain {
+		// Subagent traffic: the main agent is busy waiting on it.
+		s.phase, s.tool = phaseWorking, ""
+		return
+	}
+	s.lastID = r.UUID
+	s.at = r.Timestamp
+
+	var text string
+	var blocks []contentBlock
+	if err := json.Unmarshal(r.Message.Content, &text); err != nil {
+		json.Unmarshal(r.Message.Content, &blocks)
+	}
+
+	if r.Type == "user" {
+		if text == "" && len(blocks) > 0 && blocks[0].Type == "text" {
+			text = blocks[0].Text
+		}
+		if strings.HasPrefix(text, "[Request interrupted by user") {
+			s.phase, s.tool = phaseInterrupted, ""
+			return
+		}
+		// A prompt or a tool result: the model is up next.
+		s.phase, s.tool = phaseWorking, ""
+		return
+	}
+
+	// Claude writes one assistant record per content block.
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			s.lastMessage = b.Text
+		case "tool_use":
+			s.phase, s.tool = phaseTool, b.Name
+			return
+		}
+	}
+	switch r.Message.StopReason {
+	case "end_turn", "stop_sequence", "max_tokens":
+		s.phase, s.tool = phaseWaiting, ""
+	default:
+		s.phase, s.tool = phaseWorking, ""
+	}
+}
+
+// applyTranscript lets the transcript override the scraped status of a
+// Claude pane. It returns a completion signature while the agent waits.
+// prompt is set when the pane shows an approval prompt: Claude records
+// the tool_use before asking, so the transcript says "tool" while the
+// agent waits for the user.
+func applyTranscript(status string, s *transcriptState, now time.Time, prompt bool) (string, string) {
+	if s == nil || !strings.HasPrefix(status, "c ") {
+		return status, ""
+	}
+	icon := strings.TrimPrefix(status, "c ")
+	if icon == "🗜️" || strings.Contains(icon, "🚪") {
+		return status, ""
+	}
+
+	switch s.phase {
+	case phaseWaiting:
+		return "c 💤", "transcript:" + s.lastID
+	case phaseInterrupted:
+		return "c ✋", "transcript:" + s.lastID
+	}
+	if now.Sub(s.at) > transcriptWorkingMaxAge {
+		return status, ""
+	}
+	if icon != "💤" && icon != "✋" {
+		return status, "" // child processes already say what is running
+	}
+	if icon == "💤" && prompt {
+		return status, ""
+	}
+	if s.phase == phaseTool && (s.tool == "WebFetch" || s.tool == "WebSearch") {
+		return "c 🌐", ""
+	}
+	return "c 🧠", ""
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func pollFixture(t *testing.T, name string) *transcriptState {
+	t.Helper()
+	paneID := "%transcript-" + name
+	t.Cleanup(func() { delete(paneTranscript, paneID) })
+	return pollTranscript(paneID, filepath.Join("testdata", "claude", name))
+}
+
+func TestPollTranscript_Fixtures(t *testing.T) {
+	tests := []struct {
+		file        string
+		phase       string
+		tool        string
+		lastMessage string
+	}{
+		{"waiting.jsonl", phaseWaiting, "", "The test is fixed: the parser now sorts map keys before comparing."},
+		{"tool-running.jsonl", phaseTool, "WebFetch", "I'll fetch them."},
+		{"working.jsonl", phaseWorking, "", ""},
+		{"interrupted.jsonl", phaseInterrupted, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			s := pollFixture(t, tt.file)
+			if s == nil {
+				t.Fatal("expected transcript state")
+			}
+			if s.phase != tt.phase || s.tool != tt.tool || s.lastMessage != tt.lastMessage {
+				t.Errorf("state = %+v, want phase=%q tool=%q lastMessage=%q", *s, tt.phase, tt.tool, tt.lastMessage)
+			}
+		})
+	}
+}
+
+func TestPollTranscript_Incremental(t *testing.T) {
+	paneID := "%transcript-incremental"
+	defer delete(paneTranscript, paneID)
+
+	fixture, err := os.ReadFile(filepath.Join("testdata", "claude", "waiting.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	// Everything up to the final assistant record, which is cut mid-line.
+	cut := len(fixture) - 200
+	os.WriteFile(path, fixture[:cut], 0644)
+	s := pollTranscript(paneID, path)
+	if s == nil || s.phase != phaseWorking {
+		t.Fatalf("after tool result the agent should be working, got %+v", s)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write(fixture[cut:])
+	f.Close()
+	s = pollTranscript(paneID, path)
+	if s == nil || s.phase != phaseWaiting {
+		t.Fatalf("after end_turn the agent should be waiting, got %+v", s)
+	}
+	if offset := paneTranscript[paneID].offset; offset != int64(len(fixture)) {
+		t.Errorf("offset = %d, want %d", offset, len(fixture))
+	}
+}
+
+func TestApplyTranscript(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		status  string
+		state   transcriptState
+		prompt  bool
+		want    string
+		wantSig bool
+	}{
+		{"waiting beats stale spinner", "c 🧠", transcriptState{phase: phaseWaiting, lastID: "a3", at: now}, false, "c 💤", true},
+		{"working beats idle prompt", "c 💤", transcriptState{phase: phaseWorking, at: now}, false, "c 🧠", false},
+		{"web tool", "c 💤", transcriptState{phase: phaseTool, tool: "WebFetch", at: now}, false, "c 🌐", false},
+		{"approval prompt beats pending tool", "c 💤", transcriptState{phase: phaseTool, tool: "Bash", at: now}, true, "c 💤", false},
+		{"child icon kept", "c 🔨", transcriptState{phase: phaseTool, tool: "Bash", at: now}, false, "c 🔨", false},
+		{"interrupted", "c 🧠", transcriptState{phase: phaseInterrupted, lastID: "u2", at: now}, false, "c ✋", true},
+		{"old working transcript ignored", "c 💤", transcriptState{phase: phaseWorking, at: now.Add(-time.Hour)}, false, "c 💤", false},
+		{"codex untouched", "x 🧠", transcriptState{phase: phaseWaiting, at: now}, false, "x 🧠", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			got, sig := applyTranscript(tt.status, &state, now, tt.prompt)
+			if got != tt.want || (sig != "") != tt.wantSig {
+				t.Errorf("applyTranscript() = (%q, %q), want %q (sig=%v)", got, sig, tt.want, tt.wantSig)
+			}
+		})
+	}
+}
+
+func TestClaudeProjectSlug(t *testing.T) {
+	tests := map[string]string{
+		"/root/module":             "-root-module",
+		"/home/dev/my.app/sub_dir": "-home-dev-my-app-sub-dir",
+	}
+	for cwd, want := range tests {
+		if got := claudeProjectSlug(cwd); got != want {
+			t.Errorf("claudeProjectSlug(%q) = %q, want %q", cwd, got, want)
+		}
+	}
+}
+
+func TestNewestJSONL(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.jsonl")
+	cur := filepath.Join(dir, "current.jsonl")
+	os.WriteFile(old, []byte("{}\n"), 0644)
+	os.WriteFile(cur, []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+	start := time.Now()
+	os.Chtimes(old, start.Add(-time.Hour), start.Add(-time.Hour))
+	os.Chtimes(cur, start.Add(time.Second), start.Add(time.Second))
+
+	if got := newestJSONL(dir, time.Time{}); got != cur {
+		t.Errorf("newestJSONL() = %q, want %q", got, cur)
+	}
+	if got := newestJSONL(dir, start.Add(time.Minute)); got != "" {
+		t.Errorf("transcripts older than the agent should be skipped, got %q", got)
+	}
+}
+
+func TestProcStartTime_Self(t *testing.T) {
+	started := procStartTime(os.Getpid())
+	if started.IsZero() || started.After(time.Now()) || time.Since(started) > time.Hour {
+		t.Errorf("procStartTime(self) = %v", started)
+	}
+}
+
+func TestPollTranscript_PanesInOneWindow(t *testing.T) {
+	waiting := filepath.Join("testdata", "claude", "waiting.jsonl")
+	working := filepath.Join("testdata", "claude", "working.jsonl")
+	defer delete(paneTranscript, "%21")
+	defer delete(paneTranscript, "%22")
+
+	var first *transcriptTail
+	for range 2 {
+		if s := pollTranscript("%21", waiting); s == nil || s.phase != phaseWaiting {
+			t.Fatalf("pane %%21: %+v", s)
+		}
+		if s := pollTranscript("%22", working); s == nil || s.phase != phaseWorking {
+			t.Fatalf("pane %%22: %+v", s)
+		}
+		if first == nil {
+			first = paneTranscript["%21"]
+		}
+	}
+	if paneTranscript["%21"] != first {
+		t.Error("polling the other pane restarted the tail")
+	}
+}
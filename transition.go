@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Transition hooks: cfg.OnTransition runs shell commands when a window
+// moves between status classes, e.g. working -> idle. Details are passed
+// in TMUX_AI_* environment variables. Commands run in the background with
+// a timeout, at most cfg.TransitionConcurrency at a time; the rest wait in
+// a short queue, and anything beyond that is dropped and logged so a stuck
+// script can't pile up work.
+// Muted windows and quiet hours skip hooks unless they set when_muted.
+
+// Status classes, as used in "from"/"to".
+const (
+	classNone        = "none" // no agent in the window
+	classWorking     = "working"
+	classIdle        = "idle"
+	classPermission  = "permission" // idle, waiting for an approval
+	classInterrupted = "interrupted"
+	classExited      = "exited"
+)
+
+const defaultTransitionTimeout = 10 * time.Second
+
+type transitionHook struct {
+	From    string    `json:"from"` // a class, or "any"/"" for every class
+	To      string    `json:"to"`
+	Run     string    `json:"run"` // passed to sh -c
+	Timeout *duration `json:"timeout"`
+	// WhenMuted runs the hook for muted windows and in quiet hours too.
+	WhenMuted bool `json:"when_muted"`
+}
+
+func (h transitionHook) matches(from, to string) bool {
+	return (h.From == "" || h.From == "any" || h.From == from) &&
+		(h.To == "" || h.To == "any" || h.To == to)
+}
+
+func (h transitionHook) timeout() time.Duration {
+	if h.Timeout == nil {
+		return defaultTransitionTimeout
+	}
+	return h.Timeout.Duration
+}
+
+func (h transitionHook) validate() error {
+	for _, c := range []string{h.From, h.To} {
+		switch c {
+		case "", "any", classNone, classWorking, classIdle, classPermission,
+			classInterrupted, classExited:
+		default:
+			return fmt.Errorf("on_transition: unknown status class %q", c)
+		}
+	}
+	if strings.TrimSpace(h.Run) == "" {
+		return fmt.Errorf("on_transition: %s -> %s has no run command", h.From, h.To)
+	}
+	return nil
+}
+
+// windowClass is the last status class and raw status of each window.
+var windowClass = make(map[string]classState)
+
+type classState struct {
+	class  string
+	status string
+	cwd    string
+}
+
+// statusClass buckets a raw status for transition hooks.
+func statusClass(rawStatus string, exit *exitRecord, permission bool) string {
+	switch {
+	case rawStatus == "":
+		return classNone
+	case exit != nil:
+		return classExited
+	case strings.HasSuffix(rawStatus, "✋"):
+		return classInterrupted
+	case strings.HasSuffix(rawStatus, "💤"):
+		if permission {
+			return classPermission
+		}
+		return classIdle
+	}
+	return classWorking
+}
+
+type transition struct {
+	window, paneID, agent string
+	windowID              string // "@3", while window is a "session:index" target
+	oldStatus, newStatus  string
+	from, to              string
+	cwd, message          string
+	muted                 bool // window muted or quiet hours
+}
+
+func (t transition) env() []string {
+	return []string{
+		"TMUX_AI_WINDOW=" + t.window,
+		"TMUX_AI_WINDOW_ID=" + t.windowID,
+		"TMUX_AI_SESSION=" + windowSession(t.window),
+		"TMUX_AI_PANE=" + t.paneID,
+		"TMUX_AI_AGENT=" + t.agent,
+		"TMUX_AI_OLD_STATUS=" + t.oldStatus,
+		"TMUX_AI_NEW_STATUS=" + t.newStatus,
+		"TMUX_AI_FROM=" + t.from,
+		"TMUX_AI_TO=" + t.to,
+		"TMUX_AI_CWD=" + t.cwd,
+		"TMUX_AI_MESSAGE=" + t.message,
+	}
+}
+
+// maxQueuedTransitions bounds the commands waiting for a free slot.
+const maxQueuedTransitions = 32
+
+type queuedHook struct {
+	hook transitionHook
+	t    transition
+}
+
+var (
+	transitionMu      sync.Mutex
+	transitionRunning int
+	transitionQueue   []queuedHook
+	// transitionDone is signalled when a hook command finishes; tests
+	// wait on it.
+	transitionDone = make(chan struct{}, 64)
+)
+
+// fireTransition starts every hook matching t, up to limit at a time, and
+// queues the rest.
+func fireTransition(hooks []transitionHook, t transition, limit int) {
+	for _, h := range hooks {
+		if !h.matches(t.from, t.to) || t.muted && !h.WhenMuted {
+			continue
+		}
+		if dryRun {
+			if logDryRun {
+				log.Printf("dry-run: would run on_transition %q for %s (%s -> %s)", h.Run, t.window, t.from, t.to)
+			}
+			continue
+		}
+		transitionMu.Lock()
+		switch {
+		case transitionRunning < limit:
+			transitionRunning++
+			go runTransitionHook(h, t)
+		case len(transitionQueue) < maxQueuedTransitions:
+			transitionQueue = append(transitionQueue, queuedHook{h, t})
+		default:
+			log.Printf("on_transition %q for %s: %d commands running and %d queued, dropped",
+				h.Run, t.window, transitionRunning, len(transitionQueue))
+		}
+		transitionMu.Unlock()
+	}
+}
+
+func runTransitionHook(h transitionHook, t transition) {
+	defer func() {
+		// Hand the slot to the oldest queued command, if any.
+		transitionMu.Lock()
+		if len(transitionQueue) > 0 {
+			next := transitionQueue[0]
+			transitionQueue = transitionQueue[1:]
+			go runTransitionHook(next.hook, next.t)
+		} else {
+			transitionRunning--
+		}
+		transitionMu.Unlock()
+		select {
+		case transitionDone <- struct{}{}:
+		default:
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Run)
+	// Kill the whole process group on timeout, not just sh, and don't
+	// wait for orphans still holding the output pipe.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(), t.env()...)
+	if info, err := os.Stat(t.cwd); err == nil && info.IsDir() {
+		cmd.Dir = t.cwd
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Printf("on_transition %q for %s: timed out after %s", h.Run, t.window, h.timeout())
+		return
+	}
+	if err != nil {
+		log.Printf("on_transition %q for %s: %v: %s", h.Run, t.window, err, lastLine(out.String()))
+	}
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
+
+// classifyPanePermission reports whether the bottom of the pane shows an
+// approval prompt from Claude or Codex.
+func classifyPanePermission(content string) bool {
+	lines := strings.Split(content, "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < 15; i-- {
+		line := strings.ToLower(strings.TrimSpace(lines[i]))
+		if line == "" {
+			continue
+		}
+		checked++
+		if containsAny(line,
+			"do you want to proceed?",
+			"do you want to make this edit",
+			"do you want to create",
+			"would you like to run the following command?",
+			"would you like to make the following edits?",
+			"allow command?",
+		) {
+			return true
+		}
+	}
+	return false
+}
+
+func panePermission(pane string, paneCache map[string]*paneCapture) bool {
+	content, ok := getPaneContent(pane, paneCache)
+	return ok && classifyPanePermission(content)
+}
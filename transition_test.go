@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		status     string
+		exit       *exitRecord
+		permission bool
+		want       string
+	}{
+		{"", nil, false, classNone},
+		{"c 🧠", nil, false, classWorking},
+		{"x 🧪", nil, false, classWorking},
+		{"c 🗜️", nil, false, classWorking},
+		{"c 💤", nil, false, classIdle},
+		{"c 💤", nil, true, classPermission},
+		{"x ✋", nil, false, classInterrupted},
+		{"c 💤🚪", &exitRecord{}, false, classExited},
+	}
+	for _, tt := range tests {
+		if got := statusClass(tt.status, tt.exit, tt.permission); got != tt.want {
+			t.Errorf("statusClass(%q, %v, %v) = %q, want %q", tt.status, tt.exit, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionHook_Matches(t *testing.T) {
+	h := transitionHook{From: "working", To: "idle"}
+	if !h.matches("working", "idle") || h.matches("idle", "working") {
+		t.Error("exact from/to")
+	}
+	h = transitionHook{From: "any", To: "permission"}
+	if !h.matches("working", "permission") || h.matches("working", "idle") {
+		t.Error("any -> permission")
+	}
+	if !(transitionHook{}).matches("none", "working") {
+		t.Error("empty from/to should match everything")
+	}
+	if err := (transitionHook{To: "sleeping", Run: "true"}).validate(); err == nil {
+		t.Error("unknown class should be rejected")
+	}
+	if err := (transitionHook{To: "idle"}).validate(); err == nil {
+		t.Error("missing run should be rejected")
+	}
+}
+
+func waitTransitions(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-transitionDone:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d hook commands finished", i, n)
+		}
+	}
+}
+
+func TestFireTransition_Env(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "env")
+	hooks := []transitionHook{
+		{From: "working", To: "idle", Run: `env | grep ^TMUX_AI_ | sort > "` + out + `"; pwd >> "` + out + `"`},
+		{To: "exited", Run: "touch never"},
+	}
+	fireTransition(hooks, transition{
+		window: "dev:4", windowID: "@12", paneID: "%9", agent: "codex",
+		oldStatus: "x 🧠", newStatus: "x 💤",
+		from: "working", to: "idle",
+		cwd: dir, message: "All done",
+	}, 4)
+	waitTransitions(t, 1)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"TMUX_AI_WINDOW=dev:4", "TMUX_AI_WINDOW_ID=@12", "TMUX_AI_SESSION=dev", "TMUX_AI_PANE=%9", "TMUX_AI_AGENT=codex",
+		"TMUX_AI_OLD_STATUS=x 🧠", "TMUX_AI_NEW_STATUS=x 💤", "TMUX_AI_FROM=working", "TMUX_AI_TO=idle",
+		"TMUX_AI_CWD=" + dir, "TMUX_AI_MESSAGE=All done", "\n" + dir + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "never")); err == nil {
+		t.Error("non-matching hook ran")
+	}
+}
+
+func TestFireTransition_Muted(t *testing.T) {
+	dir := t.TempDir()
+	hooks := []transitionHook{
+		{Run: "touch skipped", To: "idle"},
+		{Run: "touch logged", To: "idle", WhenMuted: true},
+	}
+	tr := transition{window: "dev:5", from: "working", to: "idle", cwd: dir, muted: true}
+	fireTransition(hooks, tr, 4)
+	waitTransitions(t, 1)
+
+	if _, err := os.Stat(filepath.Join(dir, "skipped")); err == nil {
+		t.Error("hook ran for a muted window")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "logged")); err != nil {
+		t.Error("when_muted hook should still run")
+	}
+}
+
+func TestFireTransition_TimeoutAndLimit(t *testing.T) {
+	short := duration{50 * time.Millisecond}
+	slow := transitionHook{Run: "sleep 5", Timeout: &short}
+	start := time.Now()
+	fireTransition([]transitionHook{slow, slow, slow}, transition{window: "dev:1", from: "idle", to: "working"}, 2)
+
+	transitionMu.Lock()
+	running, queued := transitionRunning, len(transitionQueue)
+	transitionMu.Unlock()
+	if running != 2 || queued != 1 {
+		t.Errorf("running = %d, queued = %d, want 2 (limit) and 1", running, queued)
+	}
+	waitTransitions(t, 3)
+	if time.Since(start) > 3*time.Second {
+		t.Error("timeout did not stop the command")
+	}
+}
+
+func TestFireTransition_QueueFull(t *testing.T) {
+	short := duration{50 * time.Millisecond}
+	slow := transitionHook{Run: "sleep 5", Timeout: &short}
+	hooks := make([]transitionHook, 4+maxQueuedTransitions+1)
+	for i := range hooks {
+		hooks[i] = slow
+	}
+	fireTransition(hooks, transition{window: "dev:2", from: "idle", to: "working"}, 4)
+
+	transitionMu.Lock()
+	running, queued := transitionRunning, len(transitionQueue)
+	transitionMu.Unlock()
+	if running != 4 || queued != maxQueuedTransitions {
+		t.Errorf("running = %d, queued = %d, want 4 and %d", running, queued, maxQueuedTransitions)
+	}
+	waitTransitions(t, len(hooks)-1)
+	select {
+	case <-transitionDone:
+		t.Error("the command over the queue limit should have been dropped")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestClassifyPanePermission(t *testing.T) {
+	claude := "╭──────╮\n│ Bash command │\n│ rm -rf build │\n│ Do you want to proceed? │\n│ ❯ 1. Yes │\n│   2. No │\n╰──────╯"
+	codex := "▌ Would you like to run the following command?\n▌ $ go test ./...\n▌ › Yes   No"
+	if !classifyPanePermission(claude) || !classifyPanePermission(codex) {
+		t.Error("approval prompts not detected")
+	}
+	if classifyPanePermission("❯ \n  ? for shortcuts") {
+		t.Error("plain prompt is not a permission request")
+	}
+}